@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// APIKey is a configured key. Only the SHA-256 of the key is stored, as hex.
+type APIKey struct {
+	Name   string   `json:"name"`
+	SHA256 string   `json:"sha256"`
+	Scopes []string `json:"scopes"`
+}
+
+type hashedKey struct {
+	key  APIKey
+	hash []byte
+}
+
+type APIKeys struct {
+	keys []hashedKey
+}
+
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	set := &APIKeys{}
+	for i, key := range keys {
+		hash, err := hex.DecodeString(key.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %d (%s): sha256 must be 64 hex characters", i, key.Name)
+		}
+		if key.Name == "" {
+			return nil, fmt.Errorf("api key %d: name is required", i)
+		}
+		set.keys = append(set.keys, hashedKey{key: key, hash: hash})
+	}
+	return set, nil
+}
+
+// LoadAPIKeys reads a JSON array of APIKey.
+func LoadAPIKeys(reader io.Reader) (*APIKeys, error) {
+	var keys []APIKey
+	if err := json.NewDecoder(reader).Decode(&keys); err != nil {
+		return nil, fmt.Errorf("decoding api keys: %w", err)
+	}
+	return NewAPIKeys(keys)
+}
+
+// HashAPIKey returns the value to store in APIKey.SHA256 for raw.
+func HashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (k *APIKeys) Authenticate(raw string) (Principal, error) {
+	sum := sha256.Sum256([]byte(raw))
+	var match *APIKey
+	// Compare against every key so timing does not reveal which one matched.
+	for i := range k.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.keys[i].hash) == 1 {
+			match = &k.keys[i].key
+		}
+	}
+	if match == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return Principal{Subject: match.Name, Method: MethodAPIKey, Scopes: match.Scopes}, nil
+}
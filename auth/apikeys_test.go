@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	// Arrange
+	keys, err := NewAPIKeys([]APIKey{
+		{Name: "finance", SHA256: HashAPIKey("finance-secret"), Scopes: []string{ScopeMetricsRead}},
+		{Name: "admin", SHA256: HashAPIKey("admin-secret"), Scopes: []string{ScopeMetricsRead, "books:write"}},
+	})
+	assert.NoError(t, err)
+
+	// Act
+	principal, err := keys.Authenticate("admin-secret")
+	_, unknownErr := keys.Authenticate("guess")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "admin", Method: MethodAPIKey, Scopes: []string{ScopeMetricsRead, "books:write"}}, principal)
+	assert.ErrorIs(t, unknownErr, ErrInvalidCredentials)
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	// Arrange
+	document := `[{"name": "finance", "sha256": "` + HashAPIKey("finance-secret") + `", "scopes": ["metrics:read"]}]`
+
+	// Act
+	keys, err := LoadAPIKeys(strings.NewReader(document))
+
+	// Assert
+	assert.NoError(t, err)
+	principal, err := keys.Authenticate("finance-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "finance", principal.Subject)
+}
+
+func TestNewAPIKeys_RejectsInvalidEntries(t *testing.T) {
+	// Act
+	_, badHash := NewAPIKeys([]APIKey{{Name: "x", SHA256: "plaintext"}})
+	_, noName := NewAPIKeys([]APIKey{{SHA256: HashAPIKey("x")}})
+
+	// Assert
+	assert.Error(t, badHash)
+	assert.Error(t, noName)
+}
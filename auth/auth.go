@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrUnauthenticated    = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInsufficientScope  = errors.New("insufficient scope")
+)
+
+const (
+	ScopeMetricsRead = "metrics:read"
+
+	APIKeyHeader = "X-API-Key"
+)
+
+type Method string
+
+const (
+	MethodAPIKey Method = "api_key"
+	MethodJWT    Method = "jwt"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Method  Method
+	Scopes  []string
+}
+
+func (p Principal) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, granted := range p.Scopes {
+			if granted == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Authenticator accepts an X-API-Key header or an Authorization bearer JWT.
+// Either source may be nil, in which case those credentials are rejected.
+type Authenticator struct {
+	APIKeys *APIKeys
+	JWT     *JWTVerifier
+}
+
+func (a *Authenticator) Authenticate(req *http.Request) (Principal, error) {
+	if key := req.Header.Get(APIKeyHeader); key != "" {
+		if a.APIKeys == nil {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return a.APIKeys.Authenticate(key)
+	}
+
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if a.JWT == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return a.JWT.Verify(strings.TrimSpace(token))
+}
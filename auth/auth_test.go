@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_HasScopes(t *testing.T) {
+	// Arrange
+	principal := Principal{Scopes: []string{ScopeMetricsRead}}
+
+	// Act & Assert
+	assert.True(t, principal.HasScopes())
+	assert.True(t, principal.HasScopes(ScopeMetricsRead))
+	assert.False(t, principal.HasScopes(ScopeMetricsRead, "books:write"))
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	keys, _ := NewAPIKeys([]APIKey{{Name: "finance", SHA256: HashAPIKey("finance-secret"), Scopes: []string{ScopeMetricsRead}}})
+	full := &Authenticator{APIKeys: keys, JWT: newTestVerifier(t)}
+	token := signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, validClaims(), testSecret)
+
+	tests := []struct {
+		name          string
+		authenticator *Authenticator
+		headers       map[string]string
+		subject       string
+		err           error
+	}{
+		{"api key", full, map[string]string{APIKeyHeader: "finance-secret"}, "finance", nil},
+		{"bearer token", full, map[string]string{"Authorization": "Bearer " + token}, "finance-dashboard", nil},
+		{"lowercase scheme", full, map[string]string{"Authorization": "bearer " + token}, "finance-dashboard", nil},
+		{"no credentials", full, nil, "", ErrUnauthenticated},
+		{"basic auth", full, map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, "", ErrInvalidCredentials},
+		{"api keys not configured", &Authenticator{}, map[string]string{APIKeyHeader: "finance-secret"}, "", ErrInvalidCredentials},
+		{"jwt not configured", &Authenticator{}, map[string]string{"Authorization": "Bearer " + token}, "", ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			// Act
+			principal, err := tt.authenticator.Authenticate(req)
+
+			// Assert
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.subject, principal.Subject)
+		})
+	}
+}
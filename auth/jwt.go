@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	minHMACKeyBytes = 32
+	minRSAKeyBits   = 2048
+)
+
+// JWK is the subset of RFC 7517 needed for HS256 ("oct") and RS256 ("RSA")
+// verification keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	K   string `json:"k,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type verificationKey struct {
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// KeySet holds verification keys by key ID. The algorithm of each key is
+// fixed by its type, so a token cannot choose how it is verified.
+type KeySet struct {
+	keys map[string]verificationKey
+}
+
+func NewKeySet(jwks []JWK) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]verificationKey)}
+	for i, jwk := range jwks {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %d (%s): %w", i, jwk.Kid, err)
+		}
+		if _, exists := set.keys[jwk.Kid]; exists {
+			return nil, fmt.Errorf("jwk %d: duplicate kid %q", i, jwk.Kid)
+		}
+		set.keys[jwk.Kid] = key
+	}
+	return set, nil
+}
+
+// LoadJWKS reads a JSON Web Key Set document ({"keys": [...]}).
+func LoadJWKS(reader io.Reader) (*KeySet, error) {
+	var document struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+	return NewKeySet(document.Keys)
+}
+
+func parseJWK(jwk JWK) (verificationKey, error) {
+	switch jwk.Kty {
+	case "oct":
+		if jwk.Alg != "" && jwk.Alg != AlgHS256 {
+			return verificationKey{}, fmt.Errorf("unsupported alg %q for oct key", jwk.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(secret) < minHMACKeyBytes {
+			return verificationKey{}, fmt.Errorf("k must be at least %d base64url bytes", minHMACKeyBytes)
+		}
+		return verificationKey{alg: AlgHS256, secret: secret}, nil
+	case "RSA":
+		if jwk.Alg != "" && jwk.Alg != AlgRS256 {
+			return verificationKey{}, fmt.Errorf("unsupported alg %q for RSA key", jwk.Alg)
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return verificationKey{}, errors.New("n and e must be base64url integers")
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSAKeyBits {
+			return verificationKey{}, fmt.Errorf("rsa keys must be at least %d bits", minRSAKeyBits)
+		}
+		return verificationKey{alg: AlgRS256, public: public}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported kty %q", jwk.Kty)
+	}
+}
+
+// lookup finds the key for a token header. Tokens without kid are accepted
+// only when exactly one key of their algorithm exists.
+func (s *KeySet) lookup(kid, alg string) (verificationKey, bool) {
+	if kid != "" {
+		key, ok := s.keys[kid]
+		return key, ok && key.alg == alg
+	}
+	var found verificationKey
+	count := 0
+	for _, key := range s.keys {
+		if key.alg == alg {
+			found = key
+			count++
+		}
+	}
+	return found, count == 1
+}
+
+type JWTVerifier struct {
+	Keys *KeySet
+	// Issuer and Audience are checked when set.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew on exp and nbf.
+	Leeway time.Duration
+	Now    func() time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       []string        `json:"scp"`
+}
+
+// Verify checks a compact JWS and returns its subject and scopes. Scopes come
+// from the space-separated "scope" claim or the "scp" array. Every failure is
+// reported as ErrInvalidCredentials, wrapping the reason.
+func (v *JWTVerifier) Verify(token string) (Principal, error) {
+	principal, err := v.verify(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return principal, nil
+}
+
+func (v *JWTVerifier) verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("token is not a compact JWS")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("header: %w", err)
+	}
+	key, ok := v.Keys.lookup(header.Kid, header.Alg)
+	if !ok {
+		return Principal{}, fmt.Errorf("no %s key for kid %q", header.Alg, header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, errors.New("signature is not base64url")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch key.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return Principal{}, errors.New("signature mismatch")
+		}
+	case AlgRS256:
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key.public, crypto.SHA256, digest[:], signature); err != nil {
+			return Principal{}, errors.New("signature mismatch")
+		}
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	scopes := claims.Scp
+	if claims.Scope != "" {
+		scopes = strings.Fields(claims.Scope)
+	}
+	return Principal{Subject: claims.Subject, Method: MethodJWT, Scopes: scopes}, nil
+}
+
+func (v *JWTVerifier) checkClaims(claims jwtClaims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("exp is required")
+	}
+	if now.After(unixTime(*claims.ExpiresAt).Add(v.Leeway)) {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(v.Leeway).Before(unixTime(*claims.NotBefore)) {
+		return errors.New("token not valid yet")
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if v.Audience != "" && !audienceContains(claims.Audience, v.Audience) {
+		return errors.New("audience mismatch")
+	}
+	return nil
+}
+
+func audienceContains(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		for _, candidate := range list {
+			if candidate == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("not base64url")
+	}
+	return json.Unmarshal(raw, v)
+}
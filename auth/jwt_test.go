@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testNow    = time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testRSAKey = mustRSAKey()
+)
+
+func mustRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(header, claims map[string]any, secret []byte) string {
+	unsigned := encodeSegments(header, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + b64(mac.Sum(nil))
+}
+
+func signRS256(header, claims map[string]any, key *rsa.PrivateKey) string {
+	unsigned := encodeSegments(header, claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return unsigned + "." + b64(signature)
+}
+
+func encodeSegments(header, claims map[string]any) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	return b64(h) + "." + b64(c)
+}
+
+func testJWKS() []JWK {
+	return []JWK{
+		{Kty: "oct", Kid: "hs", K: b64(testSecret)},
+		{Kty: "RSA", Kid: "rs", Alg: AlgRS256, N: b64(testRSAKey.N.Bytes()), E: b64(big.NewInt(int64(testRSAKey.E)).Bytes())},
+	}
+}
+
+func newTestVerifier(t *testing.T) *JWTVerifier {
+	t.Helper()
+	keys, err := NewKeySet(testJWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &JWTVerifier{
+		Keys:     keys,
+		Issuer:   "https://login.example.com",
+		Audience: "bookshop",
+		Leeway:   30 * time.Second,
+		Now:      func() time.Time { return testNow },
+	}
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "finance-dashboard",
+		"iss":   "https://login.example.com",
+		"aud":   []string{"bookshop", "reports"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"nbf":   testNow.Add(-time.Minute).Unix(),
+		"scope": "metrics:read books:write",
+	}
+}
+
+func TestJWTVerifier_Verify_HS256(t *testing.T) {
+	// Arrange
+	verifier := newTestVerifier(t)
+	token := signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, validClaims(), testSecret)
+
+	// Act
+	principal, err := verifier.Verify(token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "finance-dashboard", Method: MethodJWT, Scopes: []string{"metrics:read", "books:write"}}, principal)
+}
+
+func TestJWTVerifier_Verify_RS256WithScpClaim(t *testing.T) {
+	// Arrange
+	verifier := newTestVerifier(t)
+	claims := validClaims()
+	delete(claims, "scope")
+	claims["scp"] = []string{"metrics:read"}
+	claims["aud"] = "bookshop"
+	token := signRS256(map[string]any{"alg": "RS256", "kid": "rs"}, claims, testRSAKey)
+
+	// Act
+	principal, err := verifier.Verify(token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"metrics:read"}, principal.Scopes)
+}
+
+func TestJWTVerifier_Verify_Rejects(t *testing.T) {
+	otherKey := mustRSAKey()
+	expired := validClaims()
+	expired["exp"] = testNow.Add(-time.Minute).Unix()
+	notYet := validClaims()
+	notYet["nbf"] = testNow.Add(time.Minute).Unix()
+	noExp := validClaims()
+	delete(noExp, "exp")
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example.com"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "someone-else"
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "not-a-token"},
+		{"wrong secret", signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, validClaims(), []byte("fedcba9876543210fedcba9876543210"))},
+		{"wrong rsa key", signRS256(map[string]any{"alg": "RS256", "kid": "rs"}, validClaims(), otherKey)},
+		{"alg none", encodeSegments(map[string]any{"alg": "none", "kid": "hs"}, validClaims()) + "."},
+		{"alg confusion", signHS256(map[string]any{"alg": "HS256", "kid": "rs"}, validClaims(), testRSAKey.N.Bytes())},
+		{"unknown kid", signHS256(map[string]any{"alg": "HS256", "kid": "nope"}, validClaims(), testSecret)},
+		{"expired", signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, expired, testSecret)},
+		{"not yet valid", signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, notYet, testSecret)},
+		{"missing exp", signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, noExp, testSecret)},
+		{"wrong issuer", signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, wrongIssuer, testSecret)},
+		{"wrong audience", signHS256(map[string]any{"alg": "HS256", "kid": "hs"}, wrongAudience, testSecret)},
+	}
+
+	verifier := newTestVerifier(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := verifier.Verify(tt.token)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestJWTVerifier_Verify_WithoutKidNeedsSingleKey(t *testing.T) {
+	// Arrange
+	verifier := newTestVerifier(t)
+	token := signHS256(map[string]any{"alg": "HS256"}, validClaims(), testSecret)
+
+	// Act
+	_, err := verifier.Verify(token)
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestLoadJWKS(t *testing.T) {
+	// Arrange
+	document, _ := json.Marshal(map[string]any{"keys": append(testJWKS(), JWK{Kty: "RSA", Kid: "enc", Use: "enc"})})
+
+	// Act
+	keys, err := LoadJWKS(strings.NewReader(string(document)))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, keys.keys, 2)
+}
+
+func TestNewKeySet_RejectsWeakOrUnsupportedKeys(t *testing.T) {
+	weakRSA := mustSmallRSAKey()
+	tests := []struct {
+		name string
+		jwk  JWK
+	}{
+		{"short secret", JWK{Kty: "oct", Kid: "a", K: b64([]byte("short"))}},
+		{"small rsa", JWK{Kty: "RSA", Kid: "b", N: b64(weakRSA.N.Bytes()), E: b64(big.NewInt(int64(weakRSA.E)).Bytes())}},
+		{"ec", JWK{Kty: "EC", Kid: "c"}},
+		{"mismatched alg", JWK{Kty: "oct", Kid: "d", Alg: "RS256", K: b64(testSecret)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := NewKeySet([]JWK{tt.jwk})
+
+			// Assert
+			assert.Error(t, err)
+		})
+	}
+}
+
+func mustSmallRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/services"
+)
+
+// Exit codes follow sysexits(3) so scripts can tell bad input from an
+// unavailable upstream.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 64
+	exitDataErr     = 65
+	exitNoInput     = 66
+	exitUnavailable = 69
+	exitTempFail    = 75
+	exitNoPerm      = 77
+	exitConfig      = 78
+)
+
+// exitCodes is checked in order, so specific upstream failures come before
+// the generic ErrServiceUnavailable and ErrExternalServiceFailure, as in
+// handlers.ProblemMappings.
+var exitCodes = []struct {
+	match func(error) bool
+	code  int
+}{
+	{isType[*usageError], exitUsage},
+	{isAny(
+		services.ErrInvalidMetricsOptions,
+		services.ErrInvalidDistribution,
+		services.ErrInvalidISBN,
+		services.ErrEmptyQuery,
+		services.ErrInvalidSuggestField,
+		services.ErrEmptyPrefix,
+	), exitUsage},
+	{isAny(config.ErrInvalidConfig), exitConfig},
+	{isAny(services.ErrInvalidCatalog, repositories.ErrUpstreamDecode), exitDataErr},
+	{isAny(fs.ErrNotExist), exitNoInput},
+	{isAny(repositories.ErrUpstreamAuth), exitNoPerm},
+	{isType[*repositories.UpstreamTimeoutError], exitTempFail},
+	{isAny(repositories.ErrUpstreamStatus, repositories.ErrServiceUnavailable, services.ErrExternalServiceFailure), exitUnavailable},
+}
+
+// exitCode maps err to the first matching exit code; nil is success and
+// anything unknown is a plain failure.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	for _, mapping := range exitCodes {
+		if mapping.match(err) {
+			return mapping.code
+		}
+	}
+	return exitFailure
+}
+
+func isAny(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isType[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("%w: %w", services.ErrExternalServiceFailure, err) }
+
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"success", nil, exitOK},
+		{"usage", &usageError{err: errors.New("bad flag")}, exitUsage},
+		{"invalid options", fmt.Errorf("%w: top_n", services.ErrInvalidMetricsOptions), exitUsage},
+		{"invalid config", fmt.Errorf("%w: rate", config.ErrInvalidConfig), exitConfig},
+		{"invalid catalog", services.ErrInvalidCatalog, exitDataErr},
+		{"decode", wrap(&repositories.UpstreamDecodeError{Err: errors.New("eof")}), exitDataErr},
+		{"missing file", wrap(fs.ErrNotExist), exitNoInput},
+		{"timeout", wrap(&repositories.UpstreamTimeoutError{Err: context.DeadlineExceeded}), exitTempFail},
+		{"upstream auth", wrap(&repositories.TokenError{Status: 401, Code: "invalid_client"}), exitNoPerm},
+		{"bad status", wrap(&repositories.UpstreamStatusError{Code: 500}), exitUnavailable},
+		{"unavailable", wrap(repositories.ErrServiceUnavailable), exitUnavailable},
+		{"unknown", errors.New("disk full"), exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, exitCode(tt.err))
+		})
+	}
+}
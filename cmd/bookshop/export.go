@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"educabot.com/bookshop/handlers"
+	"educabot.com/bookshop/services"
+)
+
+// export writes the same tables as GET /export/books and /export/authors.
+func (c *cli) export(ctx context.Context, args []string) error {
+	fs := c.flags("export")
+	from := fromFlag(fs)
+	cassette := addCassetteFlags(fs)
+	format := fs.String("format", "csv", "file format: "+strings.Join(handlers.ExportFormats, ", "))
+	table := fs.String("table", "books", "what to export: books or authors")
+	out := fs.String("out", "", "file to write; stdout when empty")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains(handlers.ExportFormats, *format) {
+		return &usageError{err: fmt.Errorf("--format must be one of %s", strings.Join(handlers.ExportFormats, ", "))}
+	}
+
+	repository, err := c.openSource(*from, cassette)
+	if err != nil {
+		return err
+	}
+	service := services.NewExportService(repository)
+	load := service.Books
+	switch *table {
+	case "books":
+	case "authors":
+		load = service.Authors
+	default:
+		return &usageError{err: errors.New("--table must be books or authors")}
+	}
+
+	data, err := load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		w := bufio.NewWriter(c.stdout)
+		if err := handlers.WriteExport(w, *format, data); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := handlers.WriteExport(file, *format, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
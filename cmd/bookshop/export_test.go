@@ -0,0 +1,56 @@
+package main
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport_CSV(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, testBooks)
+
+	// Act
+	code, stdout, _ := runCLI(t, nil, "export", "--from", path, "--table", "authors")
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "Author,Books,Units sold,Lowest price,Highest price,Mean price\r\n"+
+		"Robert C. Martin,1,15000,50,50,50\r\n"+
+		"Andrew Hunt,1,13000,45,45,45\r\n"+
+		"Alan Donovan,1,5000,40,40,40\r\n", stdout)
+}
+
+func TestExport_XLSXToFile(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, testBooks)
+	out := filepath.Join(t.TempDir(), "books.xlsx")
+
+	// Act
+	code, stdout, _ := runCLI(t, nil, "export", "--from", path, "--format", "xlsx", "--out", out)
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	assert.Empty(t, stdout)
+	workbook, err := zip.OpenReader(out)
+	if assert.NoError(t, err) {
+		defer workbook.Close()
+		_, err = workbook.Open("xl/worksheets/sheet1.xml")
+		assert.NoError(t, err)
+	}
+}
+
+func TestExport_InvalidFlags(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, testBooks)
+
+	// Act
+	formatCode, _, _ := runCLI(t, nil, "export", "--from", path, "--format", "pdf")
+	tableCode, _, _ := runCLI(t, nil, "export", "--from", path, "--table", "sales")
+
+	// Assert
+	assert.Equal(t, exitUsage, formatCode)
+	assert.Equal(t, exitUsage, tableCode)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/services"
+)
+
+// importBooks validates a books file and writes it, ordered by ID, as a
+// catalog file. Nothing is written when validation fails.
+func (c *cli) importBooks(ctx context.Context, args []string) error {
+	fs := c.flags("import")
+	from := fromFlag(fs)
+	cassette := addCassetteFlags(fs)
+	to := fs.String("to", "", "catalog file to write; stdout when empty")
+	output := outputFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	repository, err := c.openSource(*from, cassette)
+	if err != nil {
+		return err
+	}
+	books, err := repository.GetBooksProvider(ctx)
+	if err != nil {
+		return err
+	}
+	report := validationReport{Books: len(books), Issues: services.ValidateCatalog(books)}
+	if err := report.err(); err != nil {
+		writeReport(c.stderr, *output, report)
+		return err
+	}
+
+	books = slices.Clone(books)
+	slices.SortFunc(books, func(a, b models.Book) int { return cmp.Compare(a.ID, b.ID) })
+	if *to == "" {
+		return writeJSON(c.stdout, books)
+	}
+	if err := writeFileAtomic(*to, books); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "imported %d books into %s\n", len(books), *to)
+	return nil
+}
+
+// writeFileAtomic renames a finished temporary file over path, so readers
+// never see a partial catalog.
+func writeFileAtomic(path string, books []models.Book) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeJSON(tmp, books); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
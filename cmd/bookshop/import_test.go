@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImport_WritesSortedCatalog(t *testing.T) {
+	// Arrange
+	from := writeBooksFile(t, testBooks)
+	to := filepath.Join(t.TempDir(), "catalog.json")
+
+	// Act
+	code, stdout, stderr := runCLI(t, nil, "import", "--from", from, "--to", to)
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	assert.Empty(t, stdout)
+	assert.Equal(t, "imported 3 books into "+to+"\n", stderr)
+	payload, err := os.ReadFile(to)
+	assert.NoError(t, err)
+	var books []models.Book
+	assert.NoError(t, json.Unmarshal(payload, &books))
+	assert.Equal(t, []uint{1, 2, 3}, []uint{books[0].ID, books[1].ID, books[2].ID})
+	entries, _ := os.ReadDir(filepath.Dir(to))
+	assert.Len(t, entries, 1)
+}
+
+func TestImport_Stdout(t *testing.T) {
+	// Arrange
+	from := writeBooksFile(t, testBooks)
+
+	// Act
+	code, stdout, _ := runCLI(t, nil, "import", "--from", from)
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	var books []models.Book
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &books))
+	assert.Len(t, books, 3)
+}
+
+func TestImport_RejectsInvalidCatalog(t *testing.T) {
+	// Arrange
+	from := writeBooksFile(t, []models.Book{{ID: 1, Name: "", Author: "A"}})
+	to := filepath.Join(t.TempDir(), "catalog.json")
+
+	// Act
+	code, stdout, stderr := runCLI(t, nil, "import", "--from", from, "--to", to)
+
+	// Assert
+	assert.Equal(t, exitDataErr, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "name   is empty")
+	assert.NoFileExists(t, to)
+}
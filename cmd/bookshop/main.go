@@ -0,0 +1,112 @@
+// Command bookshop runs the API server and the catalog tasks ops scripts
+// need without it: metrics, exports, imports and payload validation.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// cli carries the process environment so commands can be run from tests.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"serve", "run the HTTP API", (*cli).serve},
+	{"metrics", "compute catalog metrics", (*cli).metrics},
+	{"export", "write the catalog or author aggregates as CSV, NDJSON or XLSX", (*cli).export},
+	{"import", "validate a books file and write it as a catalog", (*cli).importBooks},
+	{"validate", "check a catalog payload", (*cli).validate},
+}
+
+// usageError is a bad command line. Its message is printed with the usage.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+
+func (e *usageError) Unwrap() error { return e.err }
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	os.Exit(c.run(ctx, os.Args[1:]))
+}
+
+// run dispatches to a subcommand and returns the process exit code.
+func (c *cli) run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		c.usage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(c, ctx, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		if err != nil {
+			fmt.Fprintf(c.stderr, "bookshop %s: %v\n", cmd.name, err)
+		}
+		return exitCode(err)
+	}
+
+	fmt.Fprintf(c.stderr, "bookshop: unknown command %q\n", args[0])
+	c.usage()
+	return exitUsage
+}
+
+func (c *cli) usage() {
+	var b strings.Builder
+	b.WriteString("Usage: bookshop <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	b.WriteString("\nRun 'bookshop <command> -h' for the flags of a command.\n")
+	io.WriteString(c.stderr, b.String())
+}
+
+// flags returns a flag set that reports errors instead of exiting.
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("bookshop "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parse parses args and rejects positional arguments.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{err: err}
+	}
+	if fs.NArg() > 0 {
+		return &usageError{err: fmt.Errorf("unexpected argument %q", fs.Arg(0))}
+	}
+	return nil
+}
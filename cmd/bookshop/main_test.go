@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testBooks = []models.Book{
+	{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50, ISBN: "978-0132350884"},
+	{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40, ISBN: "978-0134190440"},
+	{ID: 3, Name: "The Pragmatic Programmer", Author: "Andrew Hunt", UnitsSold: 13000, Price: 45},
+}
+
+// runCLI runs the command line with env as the only BOOKSHOP_* variables.
+func runCLI(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdout: &stdout, stderr: &stderr, getenv: func(key string) string { return env[key] }}
+	code := c.run(context.Background(), args)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeBooksFile writes books as a JSON payload and returns its path.
+func writeBooksFile(t *testing.T, books any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "books.json")
+	payload, err := json.Marshal(books)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, payload, 0o644))
+	return path
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", nil, exitUsage},
+		{"help", []string{"help"}, exitOK},
+		{"unknown command", []string{"report"}, exitUsage},
+		{"command help", []string{"metrics", "-h"}, exitOK},
+		{"unknown flag", []string{"metrics", "--bogus"}, exitUsage},
+		{"positional argument", []string{"validate", "books.json"}, exitUsage},
+		{"invalid output", []string{"validate", "--output", "yaml"}, exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			code, stdout, stderr := runCLI(t, nil, tt.args...)
+
+			// Assert
+			assert.Equal(t, tt.code, code)
+			assert.Empty(t, stdout)
+			assert.NotEmpty(t, stderr)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"educabot.com/bookshop/services"
+)
+
+func (c *cli) metrics(ctx context.Context, args []string) error {
+	fs := c.flags("metrics")
+	from := fromFlag(fs)
+	cassette := addCassetteFlags(fs)
+	author := fs.String("author", "", "author whose books are counted")
+	cheapest := fs.String("cheapest", string(services.CheapestName), "cheapest book detail: name, book or ties")
+	topN := fs.Int("top-n", 0, "also list the N cheapest and N best-selling books")
+	output := outputFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	repository, err := c.openSource(*from, cassette)
+	if err != nil {
+		return err
+	}
+	service := services.NewMetricsService(repository)
+	result, err := service.ComputeMetricsWithOptions(ctx, services.MetricsOptions{
+		Author:   *author,
+		Cheapest: services.CheapestFormat(*cheapest),
+		TopN:     *topN,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == outputJSON {
+		return writeJSON(c.stdout, result)
+	}
+	rows := [][]string{
+		{"METRIC", "VALUE"},
+		{"Mean units sold", strconv.FormatUint(uint64(result.MeanUnitsSold), 10)},
+		{"Cheapest book", result.CheapestBook},
+	}
+	if *author != "" {
+		rows = append(rows, []string{"Books by " + *author, strconv.FormatUint(uint64(result.BooksWrittenByAuthor), 10)})
+	}
+	for i, book := range result.TopSellers {
+		rows = append(rows, []string{"Top seller #" + strconv.Itoa(i+1), book.Name})
+	}
+	for i, book := range result.TopCheapest {
+		rows = append(rows, []string{"Cheapest #" + strconv.Itoa(i+1), book.Name})
+	}
+	return writeTable(c.stdout, rows)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Table(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, testBooks)
+
+	// Act
+	code, stdout, _ := runCLI(t, nil, "metrics", "--from", path, "--author", "Andrew Hunt", "--top-n", "1")
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "METRIC                VALUE\n"+
+		"Mean units sold       11000\n"+
+		"Cheapest book         The Go Programming Language\n"+
+		"Books by Andrew Hunt  1\n"+
+		"Top seller #1         Clean Code\n"+
+		"Cheapest #1           The Go Programming Language\n", stdout)
+}
+
+func TestMetrics_JSONFromUpstream(t *testing.T) {
+	// Arrange
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(testBooks)
+	}))
+	defer upstream.Close()
+
+	// Act
+	code, stdout, _ := runCLI(t, nil, "metrics", "--from", upstream.URL, "--output", "json", "--cheapest", "book")
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	var result services.MetricsResult
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, uint(11000), result.MeanUnitsSold)
+	assert.Equal(t, uint(1), result.CheapestBookDetail.ID)
+}
+
+func TestMetrics_Errors(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	books := writeBooksFile(t, testBooks)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"invalid options", []string{"--from", books, "--cheapest", "bogus"}, exitUsage},
+		{"missing file", []string{"--from", filepath.Join(t.TempDir(), "missing.json")}, exitNoInput},
+		{"malformed file", []string{"--from", writeBooksFile(t, map[string]string{"books": "none"})}, exitDataErr},
+		{"upstream error", []string{"--from", failing.URL}, exitUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			code, stdout, stderr := runCLI(t, nil, append([]string{"metrics"}, tt.args...)...)
+
+			// Assert
+			assert.Equal(t, tt.code, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, "bookshop metrics: ")
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/server"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// serve runs the same API as the root main package.
+func (c *cli) serve(ctx context.Context, args []string) error {
+	fs := c.flags("serve")
+	addr := fs.String("addr", ":3000", "address to listen on")
+	from := fs.String("from", "", "catalog to serve: an http(s) URL or a books file (default BOOKSHOP_BOOKS_FILE, else the upstream API)")
+	cassetteOpts := addCassetteFlags(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(c.getenv)
+	if err != nil {
+		return err
+	}
+	cfg.Books.Cassette, err = cassetteOpts.config(cfg.Books.Cassette)
+	if err != nil {
+		return err
+	}
+	books, booksURL, err := catalogSource(cmp.Or(*from, cfg.Books.File, repositories.DefaultBooksURL), cfg.Books)
+	if err != nil {
+		return err
+	}
+	// The catalog keeps being polled until the server has shut down.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	booksRepo, wait, err := server.NewBooksRepository(workerCtx, books, booksURL)
+	if err != nil {
+		stopWorkers()
+		return err
+	}
+	defer func() {
+		stopWorkers()
+		wait()
+	}()
+	srv := &http.Server{Addr: *addr, Handler: server.NewRouter(cfg, booksRepo)}
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+	fmt.Fprintf(c.stderr, "listening on %s\n", *addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe_StopsWhenCancelled(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	var stderr bytes.Buffer
+	c := &cli{stdout: &bytes.Buffer{}, stderr: &stderr, getenv: func(string) string { return "" }}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	err := c.serve(ctx, []string{"--addr", "127.0.0.1:0", "--from", writeBooksFile(t, testBooks)})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, stderr.String(), "listening on 127.0.0.1:0")
+}
+
+func TestServe_InvalidConfig(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	env := map[string]string{"BOOKSHOP_RATE_LIMIT_RATE": "fast"}
+
+	// Act
+	code, _, stderr := runCLI(t, env, "serve", "--addr", "127.0.0.1:0")
+
+	// Assert
+	assert.Equal(t, exitConfig, code)
+	assert.Contains(t, stderr, "BOOKSHOP_RATE_LIMIT_RATE")
+}
+
+func TestServe_LoadsUpstreamCatalogAtStartup(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	upstream := testutil.NewUpstream(t, testutil.Books())
+	c := &cli{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, getenv: func(string) string { return "" }}
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+
+	// Act
+	go func() { errs <- c.serve(ctx, []string{"--addr", "127.0.0.1:0", "--from", upstream.BooksURL()}) }()
+	loaded := assert.Eventually(t, func() bool { return upstream.Requests() == 1 }, time.Second, time.Millisecond)
+	cancel()
+
+	// Assert
+	assert.True(t, loaded)
+	assert.NoError(t, <-errs)
+	assert.Equal(t, 1, upstream.Requests())
+}
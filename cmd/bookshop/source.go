@@ -0,0 +1,98 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/server"
+)
+
+// fromFlag registers --from, the catalog a command reads.
+func fromFlag(fs *flag.FlagSet) *string {
+	return fs.String("from", repositories.DefaultBooksURL, "catalog to read: an http(s) URL or a JSON, NDJSON or CSV file")
+}
+
+// cassetteFlags record an upstream catalog to a cassette file or replay it
+// from one; see repositories.CassetteTransport.
+type cassetteFlags struct {
+	file  *string
+	mode  *string
+	match *string
+}
+
+func addCassetteFlags(fs *flag.FlagSet) cassetteFlags {
+	return cassetteFlags{
+		file:  fs.String("cassette", "", "cassette file to record the upstream to or replay it from"),
+		mode:  fs.String("cassette-mode", "", "record or replay (default replay)"),
+		match: fs.String("cassette-match", "", "request parts a replayed request must match: method, host, path, query, header:<Name> (default method,path,query)"),
+	}
+}
+
+// config returns fallback with the flags that were given on top.
+func (f cassetteFlags) config(fallback config.CassetteConfig) (config.CassetteConfig, error) {
+	cassette := config.CassetteConfig{
+		File:  cmp.Or(*f.file, fallback.File),
+		Mode:  cmp.Or(*f.mode, fallback.Mode),
+		Match: fallback.Match,
+	}
+	if cassette.Mode != string(repositories.CassetteRecord) && cassette.Mode != string(repositories.CassetteReplay) {
+		return config.CassetteConfig{}, &usageError{err: fmt.Errorf("--cassette-mode must be record or replay")}
+	}
+	if *f.match != "" {
+		cassette.Match = nil
+		for _, rule := range strings.Split(*f.match, ",") {
+			rule = strings.TrimSpace(rule)
+			if !repositories.ValidCassetteMatch(rule) {
+				return config.CassetteConfig{}, &usageError{err: fmt.Errorf("--cassette-match has unknown rule %q", rule)}
+			}
+			cassette.Match = append(cassette.Match, rule)
+		}
+	}
+	return cassette, nil
+}
+
+// openSource opens from with the BOOKSHOP_* upstream and cassette settings,
+// cassette flags taking precedence.
+func (c *cli) openSource(from string, cassette cassetteFlags) (repositories.BooksRepository, error) {
+	cfg, err := config.Load(c.getenv)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Books.Cassette, err = cassette.config(cfg.Books.Cassette); err != nil {
+		return nil, err
+	}
+	return openRepository(from, cfg.Books)
+}
+
+// openRepository reads from an upstream URL, reached as books.Upstream and
+// books.Cassette say, or, for anything else, from a books file. A file is
+// loaded straight away, so a missing or malformed one is reported here.
+func openRepository(from string, books config.BooksConfig) (repositories.BooksRepository, error) {
+	books, booksURL, err := catalogSource(from, books)
+	if err != nil {
+		return nil, err
+	}
+	if books.File == "" {
+		return server.NewUpstreamRepository(booksURL, books)
+	}
+	return repositories.NewFileBooksRepository(books.File, "")
+}
+
+// catalogSource splits from into the books file or upstream URL that
+// server.NewBooksRepository expects.
+func catalogSource(from string, books config.BooksConfig) (config.BooksConfig, string, error) {
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		books.File = ""
+		return books, from, nil
+	}
+	if books.Cassette.File != "" {
+		return books, "", &usageError{err: errors.New("--cassette needs an http(s) catalog")}
+	}
+	books.File = from
+	return books, "", nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	// Arrange
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(testBooks)
+	}))
+	cassette := filepath.Join(t.TempDir(), "incident.json")
+
+	// Act
+	recordCode, _, _ := runCLI(t, nil, "metrics", "--from", upstream.URL, "--cassette", cassette, "--cassette-mode", "record")
+	upstream.Close()
+	replayCode, stdout, _ := runCLI(t, nil, "metrics", "--from", upstream.URL, "--cassette", cassette, "--output", "json")
+
+	// Assert
+	assert.Equal(t, exitOK, recordCode)
+	assert.Equal(t, exitOK, replayCode)
+	var result services.MetricsResult
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.Equal(t, uint(11000), result.MeanUnitsSold)
+}
+
+func TestCassette_Errors(t *testing.T) {
+	books := writeBooksFile(t, testBooks)
+	url := "http://127.0.0.1:1/books"
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"unknown mode", []string{"--from", url, "--cassette", "c.json", "--cassette-mode", "rewind"}, exitUsage},
+		{"unknown match rule", []string{"--from", url, "--cassette", "c.json", "--cassette-match", "method,body"}, exitUsage},
+		{"file catalog", []string{"--from", books, "--cassette", "c.json"}, exitUsage},
+		{"missing cassette", []string{"--from", url, "--cassette", filepath.Join(t.TempDir(), "missing.json")}, exitNoInput},
+		{"no recorded match", []string{"--from", url, "--cassette", "../../repositories/testdata/cassettes/mockapi.json", "--cassette-match", "host"}, exitUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			code, stdout, stderr := runCLI(t, nil, append([]string{"validate"}, tt.args...)...)
+
+			// Assert
+			assert.Equal(t, tt.code, code, stderr)
+			assert.Empty(t, stdout)
+			assert.NotEmpty(t, stderr)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"educabot.com/bookshop/services"
+)
+
+type validationReport struct {
+	Books  int                     `json:"books"`
+	Issues []services.CatalogIssue `json:"issues"`
+}
+
+// validate fetches a catalog and lists what services.ValidateCatalog finds.
+// A payload that does not decode is reported as an upstream decode error.
+func (c *cli) validate(ctx context.Context, args []string) error {
+	fs := c.flags("validate")
+	from := fromFlag(fs)
+	cassette := addCassetteFlags(fs)
+	output := outputFlag(fs)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	repository, err := c.openSource(*from, cassette)
+	if err != nil {
+		return err
+	}
+	books, err := repository.GetBooksProvider(ctx)
+	if err != nil {
+		return err
+	}
+	report := validationReport{Books: len(books), Issues: services.ValidateCatalog(books)}
+	if err := writeReport(c.stdout, *output, report); err != nil {
+		return err
+	}
+	return report.err()
+}
+
+func (r validationReport) err() error {
+	if len(r.Issues) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d issues in %d books", services.ErrInvalidCatalog, len(r.Issues), r.Books)
+}
+
+func writeReport(w io.Writer, output string, report validationReport) error {
+	if output == outputJSON {
+		return writeJSON(w, report)
+	}
+	if len(report.Issues) == 0 {
+		_, err := fmt.Fprintf(w, "%d books, no issues\n", report.Books)
+		return err
+	}
+	rows := [][]string{{"INDEX", "ID", "FIELD", "ISSUE"}}
+	for _, issue := range report.Issues {
+		rows = append(rows, []string{strconv.Itoa(issue.Index), strconv.FormatUint(uint64(issue.ID), 10), issue.Field, issue.Message})
+	}
+	return writeTable(w, rows)
+}
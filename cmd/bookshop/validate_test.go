@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate_Clean(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, testBooks)
+
+	// Act
+	code, stdout, _ := runCLI(t, nil, "validate", "--from", path)
+
+	// Assert
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "3 books, no issues\n", stdout)
+}
+
+func TestValidate_Issues(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, []models.Book{
+		{ID: 1, Name: "First", Author: "A"},
+		{ID: 1, Name: "Tab\tin name", Author: "", ISBN: "123"},
+	})
+
+	// Act
+	tableCode, table, stderr := runCLI(t, nil, "validate", "--from", path)
+	jsonCode, encoded, _ := runCLI(t, nil, "validate", "--from", path, "--output", "json")
+
+	// Assert
+	assert.Equal(t, exitDataErr, tableCode)
+	assert.Equal(t, "INDEX  ID  FIELD   ISSUE\n"+
+		"1      1   id      duplicates book 0\n"+
+		"1      1   author  is empty\n"+
+		"1      1   isbn    isbn must have 10 or 13 digits\n", table)
+	assert.Contains(t, stderr, "invalid catalog: 3 issues in 2 books")
+	assert.Equal(t, exitDataErr, jsonCode)
+	var report validationReport
+	assert.NoError(t, json.Unmarshal([]byte(encoded), &report))
+	assert.Equal(t, 2, report.Books)
+	assert.Len(t, report.Issues, 3)
+}
+
+func TestValidate_Malformed(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, []map[string]any{{"id": "one"}})
+
+	// Act
+	code, stdout, stderr := runCLI(t, nil, "validate", "--from", path)
+
+	// Assert
+	assert.Equal(t, exitDataErr, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "books.json:1:12: json: cannot unmarshal string")
+}
@@ -0,0 +1,440 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/services"
+)
+
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+type RateLimitKey string
+
+const (
+	RateLimitByIP     RateLimitKey = "ip"
+	RateLimitByAPIKey RateLimitKey = "api_key"
+	RateLimitByRoute  RateLimitKey = "route"
+)
+
+type RateLimitConfig struct {
+	// Rate is the refill rate in requests per second. Zero disables limiting.
+	Rate    float64
+	Burst   int
+	Key     RateLimitKey
+	IdleTTL time.Duration
+}
+
+type AuthConfig struct {
+	// APIKeysFile is a JSON array of {"name", "sha256", "scopes"}.
+	APIKeysFile string
+	// JWKSFile holds the HS256 ("oct") and RS256 ("RSA") keys accepted for
+	// bearer tokens.
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+type CORSConfig struct {
+	// AllowedOrigins holds exact origins ("https://app.example.com"),
+	// wildcard subdomains ("https://*.example.com") or "*". Empty disables
+	// cross-origin access.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type ReportsConfig struct {
+	// TemplatesDir holds *.tmpl files that replace the embedded report
+	// templates of the same name.
+	TemplatesDir string
+}
+
+type RecommenderConfig struct {
+	Weights services.RecommendationWeights
+	// CoPurchasesFile is a JSON array of {"book_id", "also_bought",
+	// "count"}; CoPurchases holds what it contained.
+	CoPurchasesFile string
+	CoPurchases     []services.CoPurchase
+}
+
+type BooksConfig struct {
+	// File is a JSON, NDJSON or CSV catalog served instead of the upstream
+	// API.
+	File string
+	// PollInterval is how often File is checked for changes.
+	PollInterval time.Duration
+	// RefreshInterval is how often the upstream catalog is fetched in the
+	// background.
+	RefreshInterval time.Duration
+	Cassette        CassetteConfig
+	Upstream        UpstreamConfig
+}
+
+// UpstreamConfig tunes the HTTP client that reaches the upstream API.
+type UpstreamConfig struct {
+	Timeout      time.Duration
+	MaxIdleConns int
+	// CAFile is a PEM bundle trusted on top of the system roots.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key for mTLS.
+	CertFile string
+	KeyFile  string
+	// Proxy is a proxy URL or "direct"; empty honours HTTP_PROXY and
+	// HTTPS_PROXY.
+	Proxy     string
+	UserAgent string
+	Auth      UpstreamAuthConfig
+}
+
+// Secret is a credential. It prints as REDACTED, so logging a config never
+// reveals it.
+type Secret string
+
+func (s Secret) String() string { return "REDACTED" }
+
+func (s Secret) GoString() string { return `"REDACTED"` }
+
+func (s Secret) MarshalText() ([]byte, error) { return []byte("REDACTED"), nil }
+
+// UpstreamAuthConfig holds the credentials sent upstream. Type picks which
+// fields apply: "bearer" uses Token, "basic" Username and Password,
+// "api_key" APIKeyHeader and APIKey, and "oauth2" the client credentials
+// grant. Empty sends no credentials.
+type UpstreamAuthConfig struct {
+	Type         string
+	Token        Secret
+	Username     string
+	Password     Secret
+	APIKeyHeader string
+	APIKey       Secret
+	TokenURL     string
+	ClientID     string
+	ClientSecret Secret
+	Scopes       []string
+}
+
+// CassetteConfig records upstream exchanges to File or replays them from it.
+// An empty File leaves the upstream untouched.
+type CassetteConfig struct {
+	File string
+	// Mode is "record" or "replay".
+	Mode string
+	// Match lists the request parts a recorded exchange must share with a
+	// live one: method, host, path, query or header:<Name>.
+	Match []string
+}
+
+type Config struct {
+	RateLimit   RateLimitConfig
+	Auth        AuthConfig
+	CORS        CORSConfig
+	Reports     ReportsConfig
+	Recommender RecommenderConfig
+	Books       BooksConfig
+}
+
+func Default() Config {
+	return Config{
+		RateLimit: RateLimitConfig{
+			Rate:    10,
+			Burst:   20,
+			Key:     RateLimitByIP,
+			IdleTTL: 10 * time.Minute,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "HEAD"},
+			AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "If-Modified-Since", "If-None-Match", "X-API-Key", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Recommender: RecommenderConfig{Weights: services.DefaultRecommenderOptions().Weights},
+		Books: BooksConfig{
+			PollInterval:    2 * time.Second,
+			RefreshInterval: 30 * time.Second,
+			Cassette: CassetteConfig{
+				Mode:  "replay",
+				Match: []string{"method", "path", "query"},
+			},
+			Upstream: UpstreamConfig{
+				Timeout:      10 * time.Second,
+				MaxIdleConns: 16,
+				UserAgent:    "bookshop/1.0",
+				Auth:         UpstreamAuthConfig{APIKeyHeader: "X-API-Key"},
+			},
+		},
+	}
+}
+
+// Load reads BOOKSHOP_* variables through getenv on top of Default. Pass
+// os.Getenv in production and a map lookup in tests.
+func Load(getenv func(string) string) (Config, error) {
+	cfg := Default()
+	var errs []error
+
+	if raw := getenv("BOOKSHOP_RATE_LIMIT_RATE"); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_RATE_LIMIT_RATE must be a non-negative number", ErrInvalidConfig))
+		}
+		cfg.RateLimit.Rate = rate
+	}
+	if raw := getenv("BOOKSHOP_RATE_LIMIT_BURST"); raw != "" {
+		burst, err := strconv.Atoi(raw)
+		if err != nil || burst < 1 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_RATE_LIMIT_BURST must be a positive integer", ErrInvalidConfig))
+		}
+		cfg.RateLimit.Burst = burst
+	}
+	if raw := getenv("BOOKSHOP_RATE_LIMIT_KEY"); raw != "" {
+		switch key := RateLimitKey(raw); key {
+		case RateLimitByIP, RateLimitByAPIKey, RateLimitByRoute:
+			cfg.RateLimit.Key = key
+		default:
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_RATE_LIMIT_KEY must be ip, api_key or route", ErrInvalidConfig))
+		}
+	}
+	if raw := getenv("BOOKSHOP_RATE_LIMIT_IDLE_TTL"); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl <= 0 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_RATE_LIMIT_IDLE_TTL must be a positive duration", ErrInvalidConfig))
+		}
+		cfg.RateLimit.IdleTTL = ttl
+	}
+
+	cfg.Auth = AuthConfig{
+		APIKeysFile: getenv("BOOKSHOP_API_KEYS_FILE"),
+		JWKSFile:    getenv("BOOKSHOP_JWKS_FILE"),
+		JWTIssuer:   getenv("BOOKSHOP_JWT_ISSUER"),
+		JWTAudience: getenv("BOOKSHOP_JWT_AUDIENCE"),
+	}
+
+	cfg.Reports.TemplatesDir = getenv("BOOKSHOP_REPORT_TEMPLATES_DIR")
+
+	if raw := getenv("BOOKSHOP_RECOMMENDER_WEIGHTS"); raw != "" {
+		weights, err := services.ParseRecommendationWeights(raw, cfg.Recommender.Weights)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_RECOMMENDER_WEIGHTS: %w", ErrInvalidConfig, err))
+		}
+		cfg.Recommender.Weights = weights
+	}
+	if file := getenv("BOOKSHOP_COPURCHASES_FILE"); file != "" {
+		cfg.Recommender.CoPurchasesFile = file
+		pairs, err := readCoPurchases(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_COPURCHASES_FILE: %w", ErrInvalidConfig, err))
+		}
+		cfg.Recommender.CoPurchases = pairs
+	}
+
+	cfg.Books.File = getenv("BOOKSHOP_BOOKS_FILE")
+	if raw := getenv("BOOKSHOP_BOOKS_POLL_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_BOOKS_POLL_INTERVAL must be a positive duration", ErrInvalidConfig))
+		}
+		cfg.Books.PollInterval = interval
+	}
+	if raw := getenv("BOOKSHOP_BOOKS_REFRESH_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_BOOKS_REFRESH_INTERVAL must be a positive duration", ErrInvalidConfig))
+		}
+		cfg.Books.RefreshInterval = interval
+	}
+	cfg.Books.Cassette.File = getenv("BOOKSHOP_CASSETTE_FILE")
+	if raw := getenv("BOOKSHOP_CASSETTE_MODE"); raw != "" {
+		if raw != "record" && raw != "replay" {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_CASSETTE_MODE must be record or replay", ErrInvalidConfig))
+		}
+		cfg.Books.Cassette.Mode = raw
+	}
+	if raw := getenv("BOOKSHOP_CASSETTE_MATCH"); raw != "" {
+		cfg.Books.Cassette.Match = splitList(raw)
+		for _, rule := range cfg.Books.Cassette.Match {
+			if !repositories.ValidCassetteMatch(rule) {
+				errs = append(errs, fmt.Errorf("%w: BOOKSHOP_CASSETTE_MATCH has unknown rule %q", ErrInvalidConfig, rule))
+			}
+		}
+	}
+	if raw := getenv("BOOKSHOP_UPSTREAM_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_TIMEOUT must be a positive duration", ErrInvalidConfig))
+		}
+		cfg.Books.Upstream.Timeout = timeout
+	}
+	if raw := getenv("BOOKSHOP_UPSTREAM_MAX_IDLE_CONNS"); raw != "" {
+		maxIdle, err := strconv.Atoi(raw)
+		if err != nil || maxIdle < 1 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_MAX_IDLE_CONNS must be a positive integer", ErrInvalidConfig))
+		}
+		cfg.Books.Upstream.MaxIdleConns = maxIdle
+	}
+	cfg.Books.Upstream.CAFile = getenv("BOOKSHOP_UPSTREAM_CA_FILE")
+	cfg.Books.Upstream.CertFile = getenv("BOOKSHOP_UPSTREAM_CERT_FILE")
+	cfg.Books.Upstream.KeyFile = getenv("BOOKSHOP_UPSTREAM_KEY_FILE")
+	if (cfg.Books.Upstream.CertFile == "") != (cfg.Books.Upstream.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_CERT_FILE and BOOKSHOP_UPSTREAM_KEY_FILE must be set together", ErrInvalidConfig))
+	}
+	if raw := getenv("BOOKSHOP_UPSTREAM_PROXY"); raw != "" {
+		if proxy, err := url.Parse(raw); raw != "direct" && (err != nil || proxy.Scheme == "" || proxy.Host == "") {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_PROXY must be a URL or direct", ErrInvalidConfig))
+		}
+		cfg.Books.Upstream.Proxy = raw
+	}
+	if raw := getenv("BOOKSHOP_UPSTREAM_USER_AGENT"); raw != "" {
+		cfg.Books.Upstream.UserAgent = raw
+	}
+	upstreamAuth, err := loadUpstreamAuth(getenv, cfg.Books.Upstream.Auth)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.Books.Upstream.Auth = upstreamAuth
+
+	if raw := getenv("BOOKSHOP_CORS_ALLOWED_ORIGINS"); raw != "" {
+		cfg.CORS.AllowedOrigins = splitList(raw)
+		for _, origin := range cfg.CORS.AllowedOrigins {
+			if !validOrigin(origin) {
+				errs = append(errs, fmt.Errorf("%w: BOOKSHOP_CORS_ALLOWED_ORIGINS has invalid origin %q", ErrInvalidConfig, origin))
+			}
+		}
+	}
+	if raw := getenv("BOOKSHOP_CORS_ALLOWED_METHODS"); raw != "" {
+		cfg.CORS.AllowedMethods = splitList(strings.ToUpper(raw))
+	}
+	if raw := getenv("BOOKSHOP_CORS_ALLOWED_HEADERS"); raw != "" {
+		cfg.CORS.AllowedHeaders = splitList(raw)
+	}
+	if raw := getenv("BOOKSHOP_CORS_ALLOW_CREDENTIALS"); raw != "" {
+		allow, err := strconv.ParseBool(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_CORS_ALLOW_CREDENTIALS must be true or false", ErrInvalidConfig))
+		}
+		cfg.CORS.AllowCredentials = allow
+	}
+	if raw := getenv("BOOKSHOP_CORS_MAX_AGE"); raw != "" {
+		maxAge, err := time.ParseDuration(raw)
+		if err != nil || maxAge < 0 {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_CORS_MAX_AGE must be a non-negative duration", ErrInvalidConfig))
+		}
+		cfg.CORS.MaxAge = maxAge
+	}
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowedOrigins, "*") {
+		errs = append(errs, fmt.Errorf("%w: BOOKSHOP_CORS_ALLOWED_ORIGINS cannot be * with credentials", ErrInvalidConfig))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
+
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// validOrigin accepts "*" and scheme://host[:port], where host may start
+// with a "*." wildcard label.
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	parsed, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	return parsed.Host != "" && parsed.Path == "" && parsed.RawQuery == "" && parsed.Fragment == "" && parsed.User == nil
+}
+
+// loadUpstreamAuth reads BOOKSHOP_UPSTREAM_AUTH and the variables its type
+// needs on top of defaults.
+func loadUpstreamAuth(getenv func(string) string, defaults UpstreamAuthConfig) (UpstreamAuthConfig, error) {
+	auth := UpstreamAuthConfig{
+		Type:         getenv("BOOKSHOP_UPSTREAM_AUTH"),
+		Username:     getenv("BOOKSHOP_UPSTREAM_USERNAME"),
+		APIKeyHeader: cmp.Or(getenv("BOOKSHOP_UPSTREAM_API_KEY_HEADER"), defaults.APIKeyHeader),
+		TokenURL:     getenv("BOOKSHOP_UPSTREAM_TOKEN_URL"),
+		ClientID:     getenv("BOOKSHOP_UPSTREAM_CLIENT_ID"),
+		Scopes:       splitList(getenv("BOOKSHOP_UPSTREAM_SCOPES")),
+	}
+	var errs []error
+	secret := func(name string) Secret {
+		value, err := readSecret(getenv, name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return value
+	}
+	required := func(value, name string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_AUTH=%s needs %s", ErrInvalidConfig, auth.Type, name))
+		}
+	}
+
+	switch auth.Type {
+	case "":
+	case "bearer":
+		auth.Token = secret("BOOKSHOP_UPSTREAM_TOKEN")
+		required(string(auth.Token), "BOOKSHOP_UPSTREAM_TOKEN")
+	case "basic":
+		auth.Password = secret("BOOKSHOP_UPSTREAM_PASSWORD")
+		required(auth.Username, "BOOKSHOP_UPSTREAM_USERNAME")
+		required(string(auth.Password), "BOOKSHOP_UPSTREAM_PASSWORD")
+	case "api_key":
+		auth.APIKey = secret("BOOKSHOP_UPSTREAM_API_KEY")
+		required(string(auth.APIKey), "BOOKSHOP_UPSTREAM_API_KEY")
+	case "oauth2":
+		auth.ClientSecret = secret("BOOKSHOP_UPSTREAM_CLIENT_SECRET")
+		required(auth.ClientID, "BOOKSHOP_UPSTREAM_CLIENT_ID")
+		required(string(auth.ClientSecret), "BOOKSHOP_UPSTREAM_CLIENT_SECRET")
+		if u, err := url.Parse(auth.TokenURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_TOKEN_URL must be an http(s) URL", ErrInvalidConfig))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%w: BOOKSHOP_UPSTREAM_AUTH must be bearer, basic, api_key or oauth2", ErrInvalidConfig))
+	}
+	return auth, errors.Join(errs...)
+}
+
+func readCoPurchases(path string) ([]services.CoPurchase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return services.ParseCoPurchases(file)
+}
+
+// readSecret reads name, or the file named by name_FILE with trailing
+// whitespace trimmed. Errors never include the value.
+func readSecret(getenv func(string) string, name string) (Secret, error) {
+	value, file := getenv(name), getenv(name+"_FILE")
+	switch {
+	case value != "" && file != "":
+		return "", fmt.Errorf("%w: set %s or %s_FILE, not both", ErrInvalidConfig, name, name)
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s_FILE: %w", ErrInvalidConfig, name, err)
+		}
+		return Secret(strings.TrimRight(string(data), " \t\r\n")), nil
+	default:
+		return Secret(value), nil
+	}
+}
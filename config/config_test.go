@@ -0,0 +1,330 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	// Act
+	cfg, err := Load(env(nil))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	// Arrange
+	values := map[string]string{
+		"BOOKSHOP_RATE_LIMIT_RATE":     "2.5",
+		"BOOKSHOP_RATE_LIMIT_BURST":    "5",
+		"BOOKSHOP_RATE_LIMIT_KEY":      "api_key",
+		"BOOKSHOP_RATE_LIMIT_IDLE_TTL": "1m",
+	}
+
+	// Act
+	cfg, err := Load(env(values))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimitConfig{Rate: 2.5, Burst: 5, Key: RateLimitByAPIKey, IdleTTL: time.Minute}, cfg.RateLimit)
+}
+
+func TestLoad_Auth(t *testing.T) {
+	// Arrange
+	values := map[string]string{
+		"BOOKSHOP_API_KEYS_FILE": "/etc/bookshop/keys.json",
+		"BOOKSHOP_JWKS_FILE":     "/etc/bookshop/jwks.json",
+		"BOOKSHOP_JWT_ISSUER":    "https://login.example.com",
+		"BOOKSHOP_JWT_AUDIENCE":  "bookshop",
+	}
+
+	// Act
+	cfg, err := Load(env(values))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, AuthConfig{
+		APIKeysFile: "/etc/bookshop/keys.json",
+		JWKSFile:    "/etc/bookshop/jwks.json",
+		JWTIssuer:   "https://login.example.com",
+		JWTAudience: "bookshop",
+	}, cfg.Auth)
+}
+
+func TestLoad_RateLimitDisabled(t *testing.T) {
+	// Act
+	cfg, err := Load(env(map[string]string{"BOOKSHOP_RATE_LIMIT_RATE": "0"}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Zero(t, cfg.RateLimit.Rate)
+}
+
+func TestLoad_InvalidValuesReportEveryProblem(t *testing.T) {
+	// Arrange
+	values := map[string]string{
+		"BOOKSHOP_RATE_LIMIT_RATE":     "-1",
+		"BOOKSHOP_RATE_LIMIT_BURST":    "zero",
+		"BOOKSHOP_RATE_LIMIT_KEY":      "cookie",
+		"BOOKSHOP_RATE_LIMIT_IDLE_TTL": "forever",
+	}
+
+	// Act
+	cfg, err := Load(env(values))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	for _, name := range []string{"RATE", "BURST", "KEY", "IDLE_TTL"} {
+		assert.Contains(t, err.Error(), "BOOKSHOP_RATE_LIMIT_"+name)
+	}
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoad_CORS(t *testing.T) {
+	// Arrange
+	values := map[string]string{
+		"BOOKSHOP_CORS_ALLOWED_ORIGINS":   "https://app.example.com, https://*.example.org",
+		"BOOKSHOP_CORS_ALLOWED_METHODS":   "get,head",
+		"BOOKSHOP_CORS_ALLOWED_HEADERS":   "Authorization, X-API-Key",
+		"BOOKSHOP_CORS_ALLOW_CREDENTIALS": "true",
+		"BOOKSHOP_CORS_MAX_AGE":           "1h",
+	}
+
+	// Act
+	cfg, err := Load(env(values))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "HEAD"},
+		AllowedHeaders:   []string{"Authorization", "X-API-Key"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}, cfg.CORS)
+}
+
+func TestLoad_InvalidCORS(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		msg    string
+	}{
+		{"path in origin", map[string]string{"BOOKSHOP_CORS_ALLOWED_ORIGINS": "https://app.example.com/ui"}, "BOOKSHOP_CORS_ALLOWED_ORIGINS"},
+		{"missing scheme", map[string]string{"BOOKSHOP_CORS_ALLOWED_ORIGINS": "app.example.com"}, "BOOKSHOP_CORS_ALLOWED_ORIGINS"},
+		{"any origin with credentials", map[string]string{"BOOKSHOP_CORS_ALLOWED_ORIGINS": "*", "BOOKSHOP_CORS_ALLOW_CREDENTIALS": "true"}, "cannot be * with credentials"},
+		{"credentials", map[string]string{"BOOKSHOP_CORS_ALLOW_CREDENTIALS": "sometimes"}, "BOOKSHOP_CORS_ALLOW_CREDENTIALS"},
+		{"max age", map[string]string{"BOOKSHOP_CORS_MAX_AGE": "-1s"}, "BOOKSHOP_CORS_MAX_AGE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			cfg, err := Load(env(tt.values))
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Contains(t, err.Error(), tt.msg)
+			assert.Equal(t, Default(), cfg)
+		})
+	}
+}
+
+func TestLoad_Reports(t *testing.T) {
+	// Act
+	cfg, err := Load(env(map[string]string{"BOOKSHOP_REPORT_TEMPLATES_DIR": "/etc/bookshop/templates"}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ReportsConfig{TemplatesDir: "/etc/bookshop/templates"}, cfg.Reports)
+}
+
+func TestLoad_Recommender(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	coPurchases := filepath.Join(dir, "copurchases.json")
+	assert.NoError(t, os.WriteFile(coPurchases, []byte(`[{"book_id": 1, "also_bought": 2, "count": 10}]`), 0o600))
+	malformed := filepath.Join(dir, "malformed.json")
+	assert.NoError(t, os.WriteFile(malformed, []byte("{"), 0o600))
+
+	// Act
+	cfg, err := Load(env(map[string]string{
+		"BOOKSHOP_RECOMMENDER_WEIGHTS": "author=5,copurchase=0",
+		"BOOKSHOP_COPURCHASES_FILE":    coPurchases,
+	}))
+	_, weightsErr := Load(env(map[string]string{"BOOKSHOP_RECOMMENDER_WEIGHTS": "author=heavy"}))
+	_, missingErr := Load(env(map[string]string{"BOOKSHOP_COPURCHASES_FILE": filepath.Join(dir, "missing.json")}))
+	_, malformedErr := Load(env(map[string]string{"BOOKSHOP_COPURCHASES_FILE": malformed}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, RecommenderConfig{
+		Weights:         services.RecommendationWeights{SameAuthor: 5, PriceBand: 1, SimilarSales: 1},
+		CoPurchasesFile: coPurchases,
+		CoPurchases:     []services.CoPurchase{{BookID: 1, AlsoBought: 2, Count: 10}},
+	}, cfg.Recommender)
+	assert.ErrorIs(t, weightsErr, ErrInvalidConfig)
+	assert.ErrorContains(t, weightsErr, "BOOKSHOP_RECOMMENDER_WEIGHTS")
+	assert.ErrorIs(t, missingErr, ErrInvalidConfig)
+	assert.ErrorIs(t, missingErr, os.ErrNotExist)
+	assert.ErrorIs(t, malformedErr, ErrInvalidConfig)
+	assert.ErrorContains(t, malformedErr, "BOOKSHOP_COPURCHASES_FILE")
+}
+
+func TestLoad_Books(t *testing.T) {
+	// Act
+	cfg, err := Load(env(map[string]string{"BOOKSHOP_BOOKS_FILE": "/srv/books.csv", "BOOKSHOP_BOOKS_POLL_INTERVAL": "500ms", "BOOKSHOP_BOOKS_REFRESH_INTERVAL": "1m"}))
+	_, invalidErr := Load(env(map[string]string{"BOOKSHOP_BOOKS_POLL_INTERVAL": "0s"}))
+	_, invalidRefreshErr := Load(env(map[string]string{"BOOKSHOP_BOOKS_REFRESH_INTERVAL": "soon"}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "/srv/books.csv", cfg.Books.File)
+	assert.Equal(t, 500*time.Millisecond, cfg.Books.PollInterval)
+	assert.Equal(t, time.Minute, cfg.Books.RefreshInterval)
+	assert.ErrorIs(t, invalidErr, ErrInvalidConfig)
+	assert.ErrorIs(t, invalidRefreshErr, ErrInvalidConfig)
+}
+
+func TestLoad_Upstream(t *testing.T) {
+	// Act
+	defaults, defaultErr := Load(env(nil))
+	cfg, err := Load(env(map[string]string{
+		"BOOKSHOP_UPSTREAM_TIMEOUT":        "3s",
+		"BOOKSHOP_UPSTREAM_MAX_IDLE_CONNS": "4",
+		"BOOKSHOP_UPSTREAM_CA_FILE":        "/etc/bookshop/ca.pem",
+		"BOOKSHOP_UPSTREAM_CERT_FILE":      "/etc/bookshop/client.pem",
+		"BOOKSHOP_UPSTREAM_KEY_FILE":       "/etc/bookshop/client-key.pem",
+		"BOOKSHOP_UPSTREAM_PROXY":          "http://proxy.internal:3128",
+		"BOOKSHOP_UPSTREAM_USER_AGENT":     "bookshop-staging/1.0",
+	}))
+	_, certErr := Load(env(map[string]string{"BOOKSHOP_UPSTREAM_CERT_FILE": "/etc/bookshop/client.pem"}))
+	_, proxyErr := Load(env(map[string]string{"BOOKSHOP_UPSTREAM_PROXY": "proxy.internal"}))
+	_, timeoutErr := Load(env(map[string]string{"BOOKSHOP_UPSTREAM_TIMEOUT": "-1s"}))
+
+	// Assert
+	assert.NoError(t, defaultErr)
+	assert.Equal(t, UpstreamConfig{Timeout: 10 * time.Second, MaxIdleConns: 16, UserAgent: "bookshop/1.0", Auth: UpstreamAuthConfig{APIKeyHeader: "X-API-Key"}}, defaults.Books.Upstream)
+	assert.NoError(t, err)
+	assert.Equal(t, UpstreamConfig{
+		Timeout:      3 * time.Second,
+		MaxIdleConns: 4,
+		CAFile:       "/etc/bookshop/ca.pem",
+		CertFile:     "/etc/bookshop/client.pem",
+		KeyFile:      "/etc/bookshop/client-key.pem",
+		Proxy:        "http://proxy.internal:3128",
+		UserAgent:    "bookshop-staging/1.0",
+		Auth:         UpstreamAuthConfig{APIKeyHeader: "X-API-Key"},
+	}, cfg.Books.Upstream)
+	assert.ErrorContains(t, certErr, "must be set together")
+	assert.ErrorContains(t, proxyErr, "BOOKSHOP_UPSTREAM_PROXY")
+	assert.ErrorIs(t, timeoutErr, ErrInvalidConfig)
+}
+
+func TestLoad_UpstreamAuth(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "client-secret")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0o600))
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want UpstreamAuthConfig
+	}{
+		{"bearer", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "bearer", "BOOKSHOP_UPSTREAM_TOKEN": "t0ken"}, UpstreamAuthConfig{Type: "bearer", Token: "t0ken", APIKeyHeader: "X-API-Key"}},
+		{"basic", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "basic", "BOOKSHOP_UPSTREAM_USERNAME": "reports", "BOOKSHOP_UPSTREAM_PASSWORD_FILE": secretFile}, UpstreamAuthConfig{Type: "basic", Username: "reports", Password: "from-file", APIKeyHeader: "X-API-Key"}},
+		{"api key", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "api_key", "BOOKSHOP_UPSTREAM_API_KEY_HEADER": "X-Catalog-Key", "BOOKSHOP_UPSTREAM_API_KEY": "k3y"}, UpstreamAuthConfig{Type: "api_key", APIKeyHeader: "X-Catalog-Key", APIKey: "k3y"}},
+		{"oauth2", map[string]string{
+			"BOOKSHOP_UPSTREAM_AUTH":               "oauth2",
+			"BOOKSHOP_UPSTREAM_TOKEN_URL":          "https://auth.example.com/oauth2/token",
+			"BOOKSHOP_UPSTREAM_CLIENT_ID":          "reports",
+			"BOOKSHOP_UPSTREAM_CLIENT_SECRET_FILE": secretFile,
+			"BOOKSHOP_UPSTREAM_SCOPES":             "catalog.read, metrics.read",
+		}, UpstreamAuthConfig{Type: "oauth2", APIKeyHeader: "X-API-Key", TokenURL: "https://auth.example.com/oauth2/token", ClientID: "reports", ClientSecret: "from-file", Scopes: []string{"catalog.read", "metrics.read"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			cfg, err := Load(env(tt.env))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Books.Upstream.Auth)
+		})
+	}
+}
+
+func TestLoad_UpstreamAuthErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"unknown type", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "digest"}, "must be bearer, basic, api_key or oauth2"},
+		{"missing token", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "bearer"}, "needs BOOKSHOP_UPSTREAM_TOKEN"},
+		{"missing username", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "basic", "BOOKSHOP_UPSTREAM_PASSWORD": "pw"}, "needs BOOKSHOP_UPSTREAM_USERNAME"},
+		{"value and file", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "api_key", "BOOKSHOP_UPSTREAM_API_KEY": "k3y", "BOOKSHOP_UPSTREAM_API_KEY_FILE": "/run/secrets/key"}, "not both"},
+		{"missing file", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "bearer", "BOOKSHOP_UPSTREAM_TOKEN_FILE": "/nonexistent/token"}, "BOOKSHOP_UPSTREAM_TOKEN_FILE"},
+		{"token url", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "oauth2", "BOOKSHOP_UPSTREAM_CLIENT_ID": "reports", "BOOKSHOP_UPSTREAM_CLIENT_SECRET": "s3cr3t"}, "BOOKSHOP_UPSTREAM_TOKEN_URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := Load(env(tt.env))
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			assert.ErrorContains(t, err, tt.want)
+			assert.NotContains(t, err.Error(), "k3y")
+		})
+	}
+}
+
+func TestSecret_IsRedacted(t *testing.T) {
+	// Arrange
+	auth := UpstreamAuthConfig{Type: "bearer", Token: "t0ken"}
+
+	// Act
+	printed := fmt.Sprintf("%v %+v %#v %s", auth, auth, auth, auth.Token)
+	encoded, err := json.Marshal(auth)
+
+	// Assert
+	assert.NotContains(t, printed, "t0ken")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "t0ken")
+	assert.Equal(t, "t0ken", string(auth.Token))
+}
+
+func TestLoad_Cassette(t *testing.T) {
+	// Act
+	defaults, defaultErr := Load(env(map[string]string{"BOOKSHOP_CASSETTE_FILE": "incident.json"}))
+	cfg, err := Load(env(map[string]string{
+		"BOOKSHOP_CASSETTE_FILE":  "incident.json",
+		"BOOKSHOP_CASSETTE_MODE":  "record",
+		"BOOKSHOP_CASSETTE_MATCH": "method, host, header:Accept",
+	}))
+	_, modeErr := Load(env(map[string]string{"BOOKSHOP_CASSETTE_MODE": "rewind"}))
+	_, matchErr := Load(env(map[string]string{"BOOKSHOP_CASSETTE_MATCH": "method,body"}))
+
+	// Assert
+	assert.NoError(t, defaultErr)
+	assert.Equal(t, CassetteConfig{File: "incident.json", Mode: "replay", Match: []string{"method", "path", "query"}}, defaults.Books.Cassette)
+	assert.NoError(t, err)
+	assert.Equal(t, CassetteConfig{File: "incident.json", Mode: "record", Match: []string{"method", "host", "header:Accept"}}, cfg.Books.Cassette)
+	assert.ErrorIs(t, modeErr, ErrInvalidConfig)
+	assert.ErrorContains(t, matchErr, `unknown rule "body"`)
+}
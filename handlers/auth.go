@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"educabot.com/bookshop/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	principalKey = "principal"
+	authRealm    = `Bearer realm="bookshop"`
+)
+
+// Identify records the principal of requests with valid credentials and
+// lets every request through, so middleware that runs before RequireScopes,
+// such as the rate limiter, can tell callers apart.
+func Identify(authenticator *auth.Authenticator) gin.HandlerFunc {
+	if authenticator == nil {
+		authenticator = &auth.Authenticator{}
+	}
+	return func(ctx *gin.Context) {
+		if principal, err := authenticator.Authenticate(ctx.Request); err == nil {
+			ctx.Set(principalKey, principal)
+		}
+		ctx.Next()
+	}
+}
+
+// RequireScopes authenticates the request and rejects it unless the caller
+// holds every scope. A principal already recorded by Identify is reused. A
+// nil authenticator, or one with no API keys and no JWT verifier, rejects
+// all requests.
+func RequireScopes(authenticator *auth.Authenticator, scopes ...string) gin.HandlerFunc {
+	if authenticator == nil {
+		authenticator = &auth.Authenticator{}
+	}
+	required := strings.Join(scopes, " ")
+
+	return func(ctx *gin.Context) {
+		principal, identified := PrincipalFrom(ctx)
+		var err error
+		if !identified {
+			principal, err = authenticator.Authenticate(ctx.Request)
+		}
+		if err != nil {
+			challenge := authRealm
+			if !errors.Is(err, auth.ErrUnauthenticated) {
+				challenge += `, error="invalid_token"`
+			}
+			ctx.Header("WWW-Authenticate", challenge)
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		if !principal.HasScopes(scopes...) {
+			ctx.Header("WWW-Authenticate", fmt.Sprintf(`%s, error="insufficient_scope", scope=%q`, authRealm, required))
+			ctx.Error(fmt.Errorf("%w: requires %s", auth.ErrInsufficientScope, required))
+			ctx.Abort()
+			return
+		}
+		ctx.Set(principalKey, principal)
+		ctx.Next()
+	}
+}
+
+func PrincipalFrom(ctx *gin.Context) (auth.Principal, bool) {
+	principal, ok := ctx.Get(principalKey)
+	if !ok {
+		return auth.Principal{}, false
+	}
+	return principal.(auth.Principal), true
+}
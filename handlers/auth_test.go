@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var authTestSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func hs256Token(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]any{"alg": "HS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, authTestSecret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTestAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Name: "reader", SHA256: auth.HashAPIKey("reader-key"), Scopes: []string{auth.ScopeMetricsRead}},
+		{Name: "no-scopes", SHA256: auth.HashAPIKey("plain-key")},
+	})
+	assert.NoError(t, err)
+	jwks, err := auth.NewKeySet([]auth.JWK{{Kty: "oct", Kid: "test", K: base64.RawURLEncoding.EncodeToString(authTestSecret)}})
+	assert.NoError(t, err)
+	return &auth.Authenticator{APIKeys: keys, JWT: &auth.JWTVerifier{Keys: jwks}}
+}
+
+func newAuthTestRouter(authenticator *auth.Authenticator) *gin.Engine {
+	router := newTestRouter()
+	router.GET("/analytics", RequireScopes(authenticator, auth.ScopeMetricsRead), func(ctx *gin.Context) {
+		principal, _ := PrincipalFrom(ctx)
+		ctx.JSON(http.StatusOK, gin.H{"subject": principal.Subject})
+	})
+	return router
+}
+
+func TestRequireScopes(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		status    int
+		code      string
+		challenge string
+	}{
+		{"api key with scope", map[string]string{auth.APIKeyHeader: "reader-key"}, http.StatusOK, "", ""},
+		{"jwt with scope", map[string]string{"Authorization": "Bearer " + hs256Token(map[string]any{"sub": "svc", "exp": exp, "scope": "metrics:read"})}, http.StatusOK, "", ""},
+		{"no credentials", nil, http.StatusUnauthorized, "unauthenticated", `Bearer realm="bookshop"`},
+		{"unknown api key", map[string]string{auth.APIKeyHeader: "guess"}, http.StatusUnauthorized, "invalid_credentials", `Bearer realm="bookshop", error="invalid_token"`},
+		{"expired jwt", map[string]string{"Authorization": "Bearer " + hs256Token(map[string]any{"sub": "svc", "exp": 1, "scope": "metrics:read"})}, http.StatusUnauthorized, "invalid_credentials", `Bearer realm="bookshop", error="invalid_token"`},
+		{"api key without scope", map[string]string{auth.APIKeyHeader: "plain-key"}, http.StatusForbidden, "insufficient_scope", `Bearer realm="bookshop", error="insufficient_scope", scope="metrics:read"`},
+		{"jwt without scope", map[string]string{"Authorization": "Bearer " + hs256Token(map[string]any{"sub": "svc", "exp": exp, "scope": "books:write"})}, http.StatusForbidden, "insufficient_scope", `Bearer realm="bookshop", error="insufficient_scope", scope="metrics:read"`},
+	}
+
+	router := newAuthTestRouter(newTestAuthenticator(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w := get(router, "/analytics", tt.headers)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.challenge, w.Header().Get("WWW-Authenticate"))
+			if tt.code == "" {
+				return
+			}
+			assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+			var problem Problem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, tt.code, problem.Code)
+		})
+	}
+}
+
+func TestRequireScopes_InvalidTokenDetailIsGeneric(t *testing.T) {
+	// Arrange
+	router := newAuthTestRouter(newTestAuthenticator(t))
+
+	// Act
+	w := get(router, "/analytics", map[string]string{"Authorization": "Bearer a.b.c"})
+
+	// Assert
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "invalid credentials", problem.Detail)
+}
+
+func TestRequireScopes_NoKeySourceRejects(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator *auth.Authenticator
+		headers       map[string]string
+	}{
+		{"nil authenticator", nil, map[string]string{auth.APIKeyHeader: "reader-key"}},
+		{"no api keys or jwt", &auth.Authenticator{}, map[string]string{auth.APIKeyHeader: "reader-key"}},
+		{"no api keys or jwt with bearer", &auth.Authenticator{}, map[string]string{"Authorization": "Bearer " + hs256Token(map[string]any{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix(), "scope": "metrics:read"})}},
+		{"no credentials", &auth.Authenticator{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newAuthTestRouter(tt.authenticator)
+
+			// Act
+			w := get(router, "/analytics", tt.headers)
+
+			// Assert
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+		})
+	}
+}
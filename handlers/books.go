@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+type BooksHandler struct {
+	service *services.BooksService
+}
+
+func NewBooksHandler(service *services.BooksService) *BooksHandler {
+	return &BooksHandler{service: service}
+}
+
+func (h *BooksHandler) GetBookByISBN(ctx *gin.Context) {
+	result, err := h.service.GetBookByISBN(ctx, ctx.Param("isbn"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *BooksHandler) GetDuplicateISBNs(ctx *gin.Context) {
+	duplicates, err := h.service.DuplicateISBNs(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"duplicates": duplicates})
+}
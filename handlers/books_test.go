@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooksHandler_GetBookByISBN_Success(t *testing.T) {
+	// Arrange
+	handler := NewBooksHandler(services.NewBooksService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/books/isbn/:isbn", handler.GetBookByISBN)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/isbn/978-0-13-235088-4", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result services.ISBNLookupResult
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clean Code", result.Book.Name)
+}
+
+func TestBooksHandler_GetBookByISBN_Invalid(t *testing.T) {
+	// Arrange
+	handler := NewBooksHandler(services.NewBooksService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/books/isbn/:isbn", handler.GetBookByISBN)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/isbn/9780132350885", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response Problem
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Equal(t, "invalid isbn: isbn checksum mismatch", response.Detail)
+}
+
+func TestBooksHandler_GetBookByISBN_NotFound(t *testing.T) {
+	// Arrange
+	handler := NewBooksHandler(services.NewBooksService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/books/isbn/:isbn", handler.GetBookByISBN)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/isbn/9781491950357", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestBooksHandler_GetBookByISBN_ServiceFailure(t *testing.T) {
+	// Arrange
+	handler := NewBooksHandler(services.NewBooksService(&mockErrorRepository{}))
+	router := newTestRouter()
+	router.GET("/books/isbn/:isbn", handler.GetBookByISBN)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/isbn/9780132350884", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestBooksHandler_GetDuplicateISBNs(t *testing.T) {
+	// Arrange
+	handler := NewBooksHandler(services.NewBooksService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/books/isbn/duplicates", handler.GetDuplicateISBNs)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/isbn/duplicates", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"duplicates":[]}`, w.Body.String())
+}
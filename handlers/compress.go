@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+type CompressionOptions struct {
+	// MinSize is the smallest body, in bytes, worth compressing.
+	MinSize int
+	// SkipTypes are media types, or "type/" prefixes, that are already
+	// compressed.
+	SkipTypes []string
+}
+
+func DefaultCompressionOptions() CompressionOptions {
+	return CompressionOptions{
+		MinSize: 1024,
+		SkipTypes: []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+			"audio/", "video/", "font/woff", "font/woff2",
+			"application/zip", "application/gzip", "application/x-gzip",
+			"application/zstd", "application/x-brotli", "application/x-7z-compressed",
+			"application/pdf", "application/octet-stream", MediaTypeXLSX,
+		},
+	}
+}
+
+type encodingWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+type contentCoding struct {
+	name string
+	pool sync.Pool
+}
+
+func (c *contentCoding) get(w io.Writer) encodingWriter {
+	writer := c.pool.Get().(encodingWriter)
+	writer.Reset(w)
+	return writer
+}
+
+func (c *contentCoding) put(writer encodingWriter) {
+	writer.Reset(io.Discard)
+	c.pool.Put(writer)
+}
+
+// contentCodings are listed in server preference order, used to break ties
+// between equal q-values.
+var contentCodings = []*contentCoding{
+	{name: "br", pool: sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, 5) }}},
+	{name: "zstd", pool: sync.Pool{New: func() any {
+		encoder, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+		return encoder
+	}}},
+	{name: "gzip", pool: sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}},
+}
+
+// negotiateEncoding picks the content coding with the highest q-value in an
+// Accept-Encoding header (RFC 9110 section 12.5.3). It returns nil when the
+// response should not be encoded.
+func negotiateEncoding(header string) *contentCoding {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if key, value, found := strings.Cut(strings.TrimSpace(params), "="); found && strings.EqualFold(strings.TrimSpace(key), "q") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				continue
+			}
+			q = parsed
+		}
+		if name == "x-gzip" {
+			name = "gzip"
+		}
+		weights[name] = q
+	}
+
+	var best *contentCoding
+	bestQ := 0.0
+	for _, coding := range contentCodings {
+		q, ok := weights[coding.name]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// Compression encodes response bodies with br, zstd or gzip, whichever the
+// client's Accept-Encoding prefers. Bodies shorter than MinSize, responses
+// that already carry a Content-Encoding and SkipTypes are sent as they are.
+// Compressed responses get a weak ETag, since the bytes no longer match the
+// strong one.
+func Compression(options CompressionOptions) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		original := ctx.Writer
+		writer := &compressWriter{
+			ResponseWriter: original,
+			options:        options,
+			coding:         negotiateEncoding(ctx.Request.Header.Get("Accept-Encoding")),
+		}
+		ctx.Writer = writer
+		ctx.Next()
+		ctx.Writer = original
+		writer.finish()
+	}
+}
+
+// compressWriter holds the first MinSize bytes back until it knows whether
+// the body is worth compressing.
+type compressWriter struct {
+	gin.ResponseWriter
+	options CompressionOptions
+	coding  *contentCoding
+
+	pending []byte
+	// headerRequested records a WriteHeaderNow made before deciding.
+	headerRequested bool
+	decided         bool
+	encoder         encodingWriter
+}
+
+func (w *compressWriter) compressible() bool {
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, skip := range w.options.SkipTypes {
+		if mediaType == skip || (strings.HasSuffix(skip, "/") && strings.HasPrefix(mediaType, skip)) {
+			return false
+		}
+	}
+	return true
+}
+
+// decide commits the headers. When streaming the body size is unknown, so
+// MinSize is not applied.
+func (w *compressWriter) decide(streaming bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	header := w.ResponseWriter.Header()
+	status := w.ResponseWriter.Status()
+	if status == http.StatusNotModified {
+		addVary(header, "Accept-Encoding")
+	}
+	bodyless := status < http.StatusOK || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified
+	if !bodyless && w.compressible() {
+		addVary(header, "Accept-Encoding")
+		if w.coding != nil && (streaming || len(w.pending) >= w.options.MinSize) {
+			header.Set("Content-Encoding", w.coding.name)
+			header.Del("Content-Length")
+			if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+				header.Set("ETag", "W/"+etag)
+			}
+			w.encoder = w.coding.get(w.ResponseWriter)
+		}
+	}
+
+	pending := w.pending
+	w.pending = nil
+	w.ResponseWriter.WriteHeaderNow()
+	if len(pending) > 0 {
+		w.write(pending)
+	}
+}
+
+func (w *compressWriter) write(data []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteHeaderNow is deferred until the body is known.
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerRequested = true
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		return w.write(data)
+	}
+	w.pending = append(w.pending, data...)
+	if len(w.pending) >= w.options.MinSize {
+		w.decide(false)
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Written() bool {
+	return w.decided || w.headerRequested || len(w.pending) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	w.decide(true)
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *compressWriter) finish() {
+	if !w.decided && (w.headerRequested || len(w.pending) > 0) {
+		w.decide(false)
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.coding.put(w.encoder)
+		w.encoder = nil
+	}
+}
+
+func addVary(header http.Header, field string) {
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if name := strings.TrimSpace(existing); name == "*" || strings.EqualFold(name, field) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", field)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+var largeBody = strings.Repeat(`{"name":"Clean Code","author":"Robert C. Martin"}`, 100)
+
+func newCompressionTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Compression(DefaultCompressionOptions()), Problems(), ConditionalGET(nil, nil))
+	router.GET("/large", func(ctx *gin.Context) { ctx.Data(http.StatusOK, "application/json", []byte(largeBody)) })
+	router.GET("/small", func(ctx *gin.Context) { ctx.String(http.StatusOK, "ok") })
+	router.GET("/image", func(ctx *gin.Context) { ctx.Data(http.StatusOK, "image/png", []byte(largeBody)) })
+	router.GET("/encoded", func(ctx *gin.Context) {
+		ctx.Header("Content-Encoding", "gzip")
+		ctx.Data(http.StatusOK, "application/json", []byte(largeBody))
+	})
+	router.GET("/fail", func(ctx *gin.Context) { ctx.Error(errors.New("boom")) })
+	router.GET("/stream", func(ctx *gin.Context) {
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.String(http.StatusOK, "{\"row\":1}\n")
+		ctx.Writer.Flush()
+		ctx.String(http.StatusOK, "{\"row\":2}\n")
+	})
+	return router
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		reader = gz
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		decoder, err := zstd.NewReader(bytes.NewReader(body))
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer decoder.Close()
+		reader = decoder
+	default:
+		return string(body)
+	}
+	decoded, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return string(decoded)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"x-gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "br"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"br;q=0, zstd;q=0.8, gzip;q=0.8", "zstd"},
+		{"*", "br"},
+		{"*;q=0.5, br;q=0", "zstd"},
+		{"GZIP;Q=0.3", "gzip"},
+		{"gzip;q=0", ""},
+		{"gzip;q=bogus, zstd;q=0.1", "zstd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			// Act
+			coding := negotiateEncoding(tt.header)
+
+			// Assert
+			name := ""
+			if coding != nil {
+				name = coding.name
+			}
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+}
+
+func TestCompression_EncodesLargeBodies(t *testing.T) {
+	router := newCompressionTestRouter()
+
+	for _, encoding := range []string{"br", "zstd", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			// Act
+			w := get(router, "/large", map[string]string{"Accept-Encoding": encoding})
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Empty(t, w.Header().Get("Content-Length"))
+			assert.Less(t, w.Body.Len(), len(largeBody))
+			assert.Equal(t, largeBody, decode(t, encoding, w.Body.Bytes()))
+		})
+	}
+}
+
+func TestCompression_SendsIdentity(t *testing.T) {
+	router := newCompressionTestRouter()
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		vary    string
+	}{
+		{"no accept-encoding", "/large", nil, "Accept-Encoding"},
+		{"refused codings", "/large", map[string]string{"Accept-Encoding": "identity, *;q=0"}, "Accept-Encoding"},
+		{"small body", "/small", map[string]string{"Accept-Encoding": "gzip"}, "Accept-Encoding"},
+		{"compressed type", "/image", map[string]string{"Accept-Encoding": "gzip"}, ""},
+		{"already encoded", "/encoded", map[string]string{"Accept-Encoding": "br"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w := get(router, tt.target, tt.headers)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.vary, w.Header().Get("Vary"))
+			if tt.target != "/encoded" {
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+			}
+		})
+	}
+}
+
+func TestCompression_WeakensETagAndKeepsConditionalGET(t *testing.T) {
+	// Arrange
+	router := newCompressionTestRouter()
+	identity := get(router, "/large", nil)
+	compressed := get(router, "/large", map[string]string{"Accept-Encoding": "gzip"})
+
+	// Act
+	w := get(router, "/large", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": compressed.Header().Get("ETag")})
+
+	// Assert
+	assert.Equal(t, "W/"+identity.Header().Get("ETag"), compressed.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+}
+
+func TestCompression_StreamsFlushedBodies(t *testing.T) {
+	// Arrange
+	router := newCompressionTestRouter()
+
+	// Act
+	w := get(router, "/stream", map[string]string{"Accept-Encoding": "gzip"})
+
+	// Assert
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "{\"row\":1}\n{\"row\":2}\n", decode(t, "gzip", w.Body.Bytes()))
+}
+
+func TestCompression_RendersProblems(t *testing.T) {
+	// Arrange
+	router := newCompressionTestRouter()
+
+	// Act
+	w := get(router, "/fail", map[string]string{"Accept-Encoding": "gzip"})
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "internal_error")
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrCrossOriginRejected = errors.New("cross-origin request not allowed")
+
+// DefaultExposedHeaders are the non-safelisted response headers that browser
+// clients may read.
+var DefaultExposedHeaders = []string{
+	"ETag", "Link", "Content-Disposition", "Deprecation", "Sunset", "Retry-After", "WWW-Authenticate", RequestIDHeader,
+	"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+}
+
+type CORSOptions struct {
+	// AllowedOrigins holds exact origins, wildcard subdomains
+	// ("https://*.example.com") or "*".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight. Zero omits it.
+	MaxAge time.Duration
+}
+
+// originPattern matches an origin exactly, or any subdomain when wildcard.
+type originPattern struct {
+	prefix   string
+	suffix   string
+	wildcard bool
+}
+
+func newOriginPattern(origin string) originPattern {
+	origin = strings.ToLower(origin)
+	if scheme, host, found := strings.Cut(origin, "://*."); found {
+		return originPattern{prefix: scheme + "://", suffix: "." + host, wildcard: true}
+	}
+	return originPattern{prefix: origin}
+}
+
+func (p originPattern) matches(origin string) bool {
+	if !p.wildcard {
+		return origin == p.prefix
+	}
+	if !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	label := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	return label != "" && !strings.ContainsAny(label, "/:@")
+}
+
+// CORS answers preflight requests and adds Access-Control-* headers to
+// cross-origin requests from allowed origins. Requests from other origins,
+// and preflights asking for a method or header that is not allowed, get a
+// 403 problem. Requests without Origin, or from the API's own origin, pass
+// through untouched.
+func CORS(options CORSOptions) gin.HandlerFunc {
+	anyOrigin := slices.Contains(options.AllowedOrigins, "*")
+	var patterns []originPattern
+	for _, origin := range options.AllowedOrigins {
+		patterns = append(patterns, newOriginPattern(origin))
+	}
+	allowedMethods := strings.Join(options.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(options.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(options.ExposedHeaders, ", ")
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		origin = strings.ToLower(origin)
+		return slices.ContainsFunc(patterns, func(p originPattern) bool { return p.matches(origin) })
+	}
+
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" || sameOrigin(ctx.Request, origin) {
+			ctx.Next()
+			return
+		}
+
+		header := ctx.Writer.Header()
+		if !anyOrigin {
+			addVary(header, "Origin")
+		}
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			addVary(header, "Access-Control-Request-Method")
+			addVary(header, "Access-Control-Request-Headers")
+		}
+
+		if !allowed(origin) {
+			ctx.Error(fmt.Errorf("%w: origin %s", ErrCrossOriginRejected, origin))
+			ctx.Abort()
+			return
+		}
+
+		if anyOrigin && !options.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if options.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+			ctx.Next()
+			return
+		}
+
+		method := ctx.GetHeader("Access-Control-Request-Method")
+		if !slices.Contains(options.AllowedMethods, method) {
+			ctx.Error(fmt.Errorf("%w: method %s", ErrCrossOriginRejected, method))
+			ctx.Abort()
+			return
+		}
+		for _, name := range strings.Split(ctx.GetHeader("Access-Control-Request-Headers"), ",") {
+			name = strings.TrimSpace(name)
+			if name != "" && !slices.ContainsFunc(options.AllowedHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+				ctx.Error(fmt.Errorf("%w: header %s", ErrCrossOriginRejected, name))
+				ctx.Abort()
+				return
+			}
+		}
+
+		header.Set("Access-Control-Allow-Methods", allowedMethods)
+		if allowedHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowedHeaders)
+		}
+		if options.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(options.MaxAge/time.Second)))
+		}
+		ctx.Status(http.StatusNoContent)
+		ctx.Writer.WriteHeaderNow()
+		ctx.Abort()
+	}
+}
+
+// sameOrigin reports whether origin names the host the request was sent to.
+// Browsers send Origin on some same-origin requests too.
+func sameOrigin(req *http.Request, origin string) bool {
+	_, host, found := strings.Cut(origin, "://")
+	return found && strings.EqualFold(host, req.Host)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSTestRouter(options CORSOptions) *gin.Engine {
+	router := newTestRouter(CORS(options))
+	router.GET("/", func(ctx *gin.Context) { ctx.String(http.StatusOK, "ok") })
+	return router
+}
+
+var testCORSOptions = CORSOptions{
+	AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+	AllowedMethods:   []string{"GET", "HEAD"},
+	AllowedHeaders:   []string{"Authorization", "X-API-Key"},
+	ExposedHeaders:   []string{"ETag", RequestIDHeader},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func preflight(router *gin.Engine, origin, method, headers string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOriginPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		matches bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"https://app.example.com", "https://app.example.com:8443", false},
+		{"https://*.example.org", "https://a.example.org", true},
+		{"https://*.example.org", "https://a.b.example.org", true},
+		{"https://*.example.org", "https://example.org", false},
+		{"https://*.example.org", "https://evilexample.org", false},
+		{"https://*.example.org", "https://a.example.org.evil.com", false},
+		{"https://*.example.org", "https://evil.com/.example.org", false},
+		{"https://*.example.org:8443", "https://a.example.org:8443", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.origin, func(t *testing.T) {
+			// Act
+			matches := newOriginPattern(tt.pattern).matches(tt.origin)
+
+			// Assert
+			assert.Equal(t, tt.matches, matches)
+		})
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	// Arrange
+	router := newCORSTestRouter(testCORSOptions)
+
+	// Act
+	w := preflight(router, "https://reports.example.org", "GET", "authorization, x-api-key")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "https://reports.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, HEAD", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, X-API-Key", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+}
+
+func TestCORS_RejectsPreflight(t *testing.T) {
+	router := newCORSTestRouter(testCORSOptions)
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+	}{
+		{"origin", "https://evil.example.net", "GET", ""},
+		{"method", "https://app.example.com", "DELETE", ""},
+		{"header", "https://app.example.com", "GET", "X-Custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w := preflight(router, tt.origin, tt.method, tt.headers)
+
+			// Assert
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+			var problem Problem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "cross_origin_rejected", problem.Code)
+		})
+	}
+}
+
+func TestCORS_SimpleRequests(t *testing.T) {
+	router := newCORSTestRouter(testCORSOptions)
+
+	tests := []struct {
+		name        string
+		origin      string
+		status      int
+		allowOrigin string
+	}{
+		{"allowed origin", "https://app.example.com", http.StatusOK, "https://app.example.com"},
+		{"allowed subdomain", "https://a.example.org", http.StatusOK, "https://a.example.org"},
+		{"disallowed origin", "https://evil.example.net", http.StatusForbidden, ""},
+		{"opaque origin", "null", http.StatusForbidden, ""},
+		{"same origin", "http://example.com", http.StatusOK, ""},
+		{"no origin", "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.allowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			if tt.allowOrigin != "" {
+				assert.Equal(t, "ETag, X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+				assert.Equal(t, "Origin", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORS_AnyOriginWithoutCredentials(t *testing.T) {
+	// Arrange
+	router := newCORSTestRouter(CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+
+	// Act
+	w := get(router, "/", map[string]string{"Origin": "https://anywhere.test"})
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheAge reports how fresh the data behind responses is.
+// repositories.CatalogRefresher and repositories.FileBooksRepository
+// implement it.
+type CacheAge interface {
+	LastRefresh() time.Time
+	TTL() time.Duration
+}
+
+// ConditionalGET buffers successful GET responses, tags them with a strong
+// ETag computed from the body and answers 304 Not Modified when the client
+// already holds that version. When age is not nil, Last-Modified and
+// Cache-Control max-age follow the catalog cache.
+//
+// Handlers that stream can call ctx.Writer.Flush to bypass buffering; those
+// responses get no validators.
+func ConditionalGET(age CacheAge, now func() time.Time) gin.HandlerFunc {
+	if now == nil {
+		now = time.Now
+	}
+	return func(ctx *gin.Context) {
+		method := ctx.Request.Method
+		if method != http.MethodGet && method != http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		original := ctx.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		ctx.Writer = writer
+		ctx.Next()
+		ctx.Writer = original
+
+		if writer.flushed || !writer.wrote {
+			return
+		}
+		if writer.status != http.StatusOK {
+			writer.commit()
+			return
+		}
+
+		header := original.Header()
+		body := writer.buf.Bytes()
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+
+		var lastModified time.Time
+		if age != nil {
+			lastModified = age.LastRefresh().UTC().Truncate(time.Second)
+		}
+		if !lastModified.IsZero() && header.Get("Last-Modified") == "" {
+			header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", cacheControl(ctx, age, now()))
+		}
+
+		if notModified(ctx.Request, etag, lastModified) {
+			for _, name := range []string{"Content-Type", "Content-Length"} {
+				header.Del(name)
+			}
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		writer.commit()
+	}
+}
+
+func cacheControl(ctx *gin.Context, age CacheAge, now time.Time) string {
+	visibility := "public"
+	if _, authenticated := PrincipalFrom(ctx); authenticated {
+		visibility = "private"
+	}
+	if age == nil || age.LastRefresh().IsZero() {
+		return visibility + ", no-cache"
+	}
+	remaining := age.TTL() - now.Sub(age.LastRefresh())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fmt.Sprintf("%s, max-age=%d", visibility, int(remaining/time.Second))
+}
+
+// notModified applies RFC 9110 section 13.2.2: If-None-Match takes
+// precedence and uses weak comparison; If-Modified-Since is only consulted
+// without it.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(since)
+	}
+	return false
+}
+
+// bufferedWriter holds the response until the middleware decides between
+// 200 and 304.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf     bytes.Buffer
+	status  int
+	wrote   bool
+	flushed bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.flushed {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+		w.wrote = true
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.flushed {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.wrote = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.flushed {
+		return w.ResponseWriter.Write(data)
+	}
+	w.wrote = true
+	return w.buf.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.flushed {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.flushed {
+		return w.ResponseWriter.Size()
+	}
+	if !w.wrote {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	if w.flushed {
+		return w.ResponseWriter.Written()
+	}
+	return w.wrote
+}
+
+// Flush switches to streaming: everything buffered so far is sent and later
+// writes go straight through.
+func (w *bufferedWriter) Flush() {
+	if !w.flushed {
+		w.commit()
+		w.flushed = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *bufferedWriter) commit() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, fmt.Errorf("hijacking is not supported while buffering")
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/auth"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedCacheAge struct {
+	lastRefresh time.Time
+	ttl         time.Duration
+}
+
+func (a fixedCacheAge) LastRefresh() time.Time { return a.lastRefresh }
+func (a fixedCacheAge) TTL() time.Duration     { return a.ttl }
+
+var etagTestRefresh = time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func newETagTestRouter() *gin.Engine {
+	age := fixedCacheAge{lastRefresh: etagTestRefresh, ttl: 30 * time.Second}
+	now := func() time.Time { return etagTestRefresh.Add(10 * time.Second) }
+
+	handler := NewHandler(services.NewMetricsService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter(ConditionalGET(age, now))
+	router.GET("/", handler.GetMetrics)
+	router.GET("/fail", func(ctx *gin.Context) { ctx.Error(errors.New("boom")) })
+	router.GET("/private", func(ctx *gin.Context) {
+		ctx.Set(principalKey, auth.Principal{Subject: "finance"})
+		ctx.String(http.StatusOK, "secret")
+	})
+	router.GET("/stream", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "first,")
+		ctx.Writer.Flush()
+		ctx.String(http.StatusOK, "second")
+	})
+	return router
+}
+
+func TestConditionalGET_SetsValidators(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+
+	// Act
+	first := get(router, "/?author=Robert+C.+Martin", nil)
+	second := get(router, "/?author=Robert+C.+Martin", nil)
+	other := get(router, "/?author=Other", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, first.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+	assert.NotEqual(t, first.Header().Get("ETag"), other.Header().Get("ETag"))
+	assert.Equal(t, "Sun, 01 Mar 2026 12:00:00 GMT", first.Header().Get("Last-Modified"))
+	assert.Equal(t, "public, max-age=20", first.Header().Get("Cache-Control"))
+	assert.Contains(t, first.Body.String(), "mean_units_sold")
+}
+
+func TestConditionalGET_NotModified(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+	etag := get(router, "/", nil).Header().Get("ETag")
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak etag in list", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"stale etag", map[string]string{"If-None-Match": `"stale"`}, http.StatusOK},
+		{"stale etag wins over date", map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 11:59:59 GMT"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w := get(router, "/", tt.headers)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			if tt.status == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+				assert.Empty(t, w.Header().Get("Content-Type"))
+				assert.Equal(t, "public, max-age=20", w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestConditionalGET_LeavesErrorsToProblems(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+
+	// Act
+	w := get(router, "/fail", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestConditionalGET_PrivateForAuthenticatedCallers(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+
+	// Act
+	w := get(router, "/private", nil)
+
+	// Assert
+	assert.Equal(t, "private, max-age=20", w.Header().Get("Cache-Control"))
+}
+
+func TestConditionalGET_FlushStreams(t *testing.T) {
+	// Arrange
+	router := newETagTestRouter()
+
+	// Act
+	w := get(router, "/stream", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "first,second", w.Body.String())
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.True(t, w.Flushed)
+}
+
+func TestConditionalGET_SkipsOtherMethods(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ConditionalGET(nil, nil))
+	router.POST("/", func(ctx *gin.Context) { ctx.String(http.StatusOK, "created") })
+
+	// Act
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	// Assert
+	assert.Equal(t, "created", w.Body.String())
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestConditionalGET_NoCacheBeforeFirstRefresh(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ConditionalGET(fixedCacheAge{ttl: time.Minute}, nil))
+	router.GET("/", func(ctx *gin.Context) { ctx.String(http.StatusOK, "ok") })
+
+	// Act
+	w := get(router, "/", nil)
+
+	// Assert
+	assert.Equal(t, "public, no-cache", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MediaTypeCSV    = "text/csv"
+	MediaTypeNDJSON = "application/x-ndjson"
+	MediaTypeXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// exportFlushRows is how many rows are written between flushes to the
+	// client.
+	exportFlushRows = 500
+)
+
+type exportFormat struct {
+	mediaType   string
+	contentType string
+	extension   string
+}
+
+// ExportFormats are the format names accepted by ?format= and WriteExport.
+var ExportFormats = []string{"csv", "ndjson", "xlsx"}
+
+var exportFormats = map[string]exportFormat{
+	"csv":    {MediaTypeCSV, "text/csv; charset=utf-8; header=present", "csv"},
+	"ndjson": {MediaTypeNDJSON, MediaTypeNDJSON, "ndjson"},
+	"xlsx":   {MediaTypeXLSX, MediaTypeXLSX, "xlsx"},
+}
+
+// tableWriter encodes an export table row by row.
+type tableWriter interface {
+	WriteHeader(titles []string) error
+	WriteRow(row []any) error
+	Flush() error
+	Close() error
+}
+
+type ExportHandler struct {
+	service *services.ExportService
+}
+
+type ExportRequest struct {
+	Format string `form:"format"`
+}
+
+func NewExportHandler(service *services.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// GetBooks exports the catalog.
+func (h *ExportHandler) GetBooks(ctx *gin.Context) {
+	h.export(ctx, h.service.Books)
+}
+
+// GetAuthors exports the per-author aggregates.
+func (h *ExportHandler) GetAuthors(ctx *gin.Context) {
+	h.export(ctx, h.service.Authors)
+}
+
+// export streams a table as CSV, NDJSON or XLSX. ?format= wins over the
+// Accept header; without either, or when nothing offered is acceptable, the
+// export is CSV.
+func (h *ExportHandler) export(ctx *gin.Context, load func(context.Context) (*services.ExportTable, error)) {
+	var query ExportRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+
+	format, ok := exportFormats[query.Format]
+	switch {
+	case query.Format == "":
+		ctx.Header("Vary", "Accept")
+		switch negotiateMediaType(ctx.GetHeader("Accept"), MediaTypeCSV, MediaTypeNDJSON, MediaTypeXLSX) {
+		case MediaTypeNDJSON:
+			format = exportFormats["ndjson"]
+		case MediaTypeXLSX:
+			format = exportFormats["xlsx"]
+		default:
+			format = exportFormats["csv"]
+		}
+	case !ok:
+		ctx.Error(&QueryError{Param: "format", Err: errors.New("must be csv, ndjson or xlsx")})
+		return
+	}
+
+	table, err := load(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filename := table.Name + "." + format.extension
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	ctx.Header("Content-Type", format.contentType)
+	ctx.Status(http.StatusOK)
+
+	if err := writeTable(ctx.Writer, format, table); err != nil {
+		ctx.Error(fmt.Errorf("export %s: %w", filename, err))
+	}
+}
+
+// WriteExport encodes table in one of ExportFormats.
+func WriteExport(w io.Writer, format string, table *services.ExportTable) error {
+	exportFormat, ok := exportFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	return writeTable(w, exportFormat, table)
+}
+
+// writeTable flushes every exportFlushRows rows when w is an http.Flusher, so
+// large exports reach the client while they are being encoded.
+func writeTable(w io.Writer, format exportFormat, table *services.ExportTable) error {
+	var writer tableWriter
+	switch format.mediaType {
+	case MediaTypeXLSX:
+		xlsx, err := newXLSXWriter(w, table.Name)
+		if err != nil {
+			return err
+		}
+		writer = xlsx
+	case MediaTypeNDJSON:
+		writer = newNDJSONWriter(w, table.Columns)
+	default:
+		writer = newCSVWriter(w)
+	}
+
+	titles := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		titles[i] = column.Title
+	}
+	if err := writer.WriteHeader(titles); err != nil {
+		return err
+	}
+
+	rows := 0
+	err := table.Rows(func(row []any) error {
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// csvWriter writes RFC 4180 CSV with CRLF line endings and a header row.
+type csvWriter struct {
+	csv *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	return &csvWriter{csv: writer}
+}
+
+func (c *csvWriter) WriteHeader(titles []string) error {
+	return c.csv.Write(titles)
+}
+
+func (c *csvWriter) WriteRow(row []any) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		switch v := value.(type) {
+		case uint:
+			record[i] = strconv.FormatUint(uint64(v), 10)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = neutralizeFormula(fmt.Sprint(v))
+		}
+	}
+	return c.csv.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// neutralizeFormula prefixes text that a spreadsheet would evaluate as a
+// formula with an apostrophe, so opening an export never runs catalog data.
+func neutralizeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ndjsonWriter writes one JSON object per row, keyed by column and in column
+// order. It has no header line.
+type ndjsonWriter struct {
+	out  *bufio.Writer
+	keys [][]byte
+}
+
+func newNDJSONWriter(w io.Writer, columns []services.ExportColumn) *ndjsonWriter {
+	keys := make([][]byte, len(columns))
+	for i, column := range columns {
+		keys[i], _ = json.Marshal(column.Key)
+	}
+	return &ndjsonWriter{out: bufio.NewWriter(w), keys: keys}
+}
+
+func (n *ndjsonWriter) WriteHeader([]string) error {
+	return nil
+}
+
+func (n *ndjsonWriter) WriteRow(row []any) error {
+	n.out.WriteByte('{')
+	for i, value := range row {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			n.out.WriteByte(',')
+		}
+		n.out.Write(n.keys[i])
+		n.out.WriteByte(':')
+		n.out.Write(encoded)
+	}
+	_, err := n.out.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.out.Flush()
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.out.Flush()
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportHandler_CSV(t *testing.T) {
+	// Arrange
+	handler := NewExportHandler(services.NewExportService(&testutil.StaticBooksRepository{Books: []models.Book{
+		{ID: 2, Name: "Quotes \"inside\", and commas", Author: "Line\nBreak", UnitsSold: 10, Price: 5},
+		{ID: 1, Name: "=HYPERLINK(\"http://evil\")", Author: "@mention", UnitsSold: 3, Price: 7, ISBN: "9780134190440"},
+	}}))
+	router := newTestRouter()
+	router.GET("/export/books", handler.GetBooks)
+
+	// Act
+	w := get(router, "/export/books?format=csv", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8; header=present", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=books.csv", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "ID,Title,Author,ISBN,Units sold,Price\r\n"+
+		"1,\"'=HYPERLINK(\"\"http://evil\"\")\",'@mention,9780134190440,3,7\r\n"+
+		"2,\"Quotes \"\"inside\"\", and commas\",\"Line\r\nBreak\",,10,5\r\n", w.Body.String())
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+func TestExportHandler_NDJSON(t *testing.T) {
+	// Arrange
+	handler := NewExportHandler(services.NewExportService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/export/authors", handler.GetAuthors)
+
+	// Act
+	w := get(router, "/export/authors?format=ndjson", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MediaTypeNDJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=authors.ndjson", w.Header().Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `{"author":"Robert C. Martin","books":1,"units_sold":15000,"min_price":50,"max_price":50,"mean_price":50}`, lines[0])
+}
+
+func TestExportHandler_Negotiation(t *testing.T) {
+	handler := NewExportHandler(services.NewExportService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/export/books", handler.GetBooks)
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+		vary        string
+	}{
+		{"default", "/export/books", "", "text/csv; charset=utf-8; header=present", "Accept"},
+		{"ndjson", "/export/books", MediaTypeNDJSON, MediaTypeNDJSON, "Accept"},
+		{"xlsx", "/export/books", MediaTypeXLSX + ", text/csv;q=0.5", MediaTypeXLSX, "Accept"},
+		{"unacceptable", "/export/books", "application/json", "text/csv; charset=utf-8; header=present", "Accept"},
+		{"format wins", "/export/books?format=xlsx", MediaTypeNDJSON, MediaTypeXLSX, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w := get(router, tt.target, map[string]string{"Accept": tt.accept})
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.vary, w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestExportHandler_Streams(t *testing.T) {
+	// Arrange
+	books := make([]models.Book, 2*exportFlushRows+1)
+	for i := range books {
+		books[i] = models.Book{ID: uint(i + 1), Name: fmt.Sprintf("Book %d", i+1), Author: "Author", UnitsSold: 1, Price: 1}
+	}
+	large := newTestRouter(ConditionalGET(nil, nil))
+	large.GET("/export/books", NewExportHandler(services.NewExportService(&testutil.StaticBooksRepository{Books: books})).GetBooks)
+	small := newTestRouter(ConditionalGET(nil, nil))
+	small.GET("/export/books", NewExportHandler(services.NewExportService(mockImpls.NewMockBooksRepositories())).GetBooks)
+
+	// Act
+	streamed := get(large, "/export/books?format=ndjson", nil)
+	buffered := get(small, "/export/books?format=ndjson", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, streamed.Code)
+	assert.True(t, streamed.Flushed)
+	assert.Empty(t, streamed.Header().Get("ETag"))
+	assert.Equal(t, len(books), strings.Count(streamed.Body.String(), "\n"))
+	assert.False(t, buffered.Flushed)
+	assert.NotEmpty(t, buffered.Header().Get("ETag"))
+}
+
+func TestExportHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository repositories.BooksRepository
+		target     string
+		status     int
+		detail     string
+	}{
+		{"invalid format", mockImpls.NewMockBooksRepositories(), "/export/books?format=pdf", http.StatusBadRequest, "invalid parameter format"},
+		{"upstream failure", &mockErrorRepository{}, "/export/authors?format=xlsx", http.StatusBadGateway, "upstream_failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			handler := NewExportHandler(services.NewExportService(tt.repository))
+			router := newTestRouter()
+			router.GET("/export/books", handler.GetBooks)
+			router.GET("/export/authors", handler.GetAuthors)
+
+			// Act
+			w := get(router, tt.target, nil)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+			assert.Empty(t, w.Header().Get("Content-Disposition"))
+			assert.Contains(t, w.Body.String(), tt.detail)
+		})
+	}
+}
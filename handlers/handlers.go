@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"educabot.com/bookshop/services"
 	"github.com/gin-gonic/gin"
@@ -12,7 +15,16 @@ type Handler struct {
 }
 
 type GetMetricsRequest struct {
-	Author string `form:"author"`
+	Author   string `form:"author"`
+	Cheapest string `form:"cheapest"`
+	TopN     int    `form:"top_n"`
+}
+
+type GetDistributionRequest struct {
+	Field   string `form:"field"`
+	Buckets int    `form:"buckets"`
+	Mode    string `form:"mode"`
+	Edges   string `form:"edges"`
 }
 
 func NewHandler(service *services.MetricsService) *Handler {
@@ -22,17 +34,52 @@ func NewHandler(service *services.MetricsService) *Handler {
 func (h *Handler) GetMetrics(ctx *gin.Context) {
 	var query GetMetricsRequest
 	if err := ctx.ShouldBindQuery(&query); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		ctx.Error(&QueryError{Err: err})
 		return
 	}
 
-	result, err := h.service.ComputeMetrics(ctx, query.Author)
+	result, err := h.service.ComputeMetricsWithOptions(ctx, services.MetricsOptions{
+		Author:   query.Author,
+		Cheapest: services.CheapestFormat(query.Cheapest),
+		TopN:     query.TopN,
+	})
 	if err != nil {
-		if err == services.ErrExternalServiceFailure {
-			ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
-			return
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetDistribution(ctx *gin.Context) {
+	var query GetDistributionRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+
+	options := services.DistributionOptions{
+		Field:   services.DistributionField(query.Field),
+		Mode:    services.BucketMode(query.Mode),
+		Buckets: query.Buckets,
+	}
+	if query.Edges != "" {
+		if options.Mode == "" {
+			options.Mode = services.BucketsCustom
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		for _, raw := range strings.Split(query.Edges, ",") {
+			edge, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err != nil {
+				ctx.Error(&QueryError{Param: "edges", Err: errors.New("must be comma-separated numbers")})
+				return
+			}
+			options.Edges = append(options.Edges, edge)
+		}
+	}
+
+	result, err := h.service.ComputeDistribution(ctx, options)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
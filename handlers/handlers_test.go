@@ -25,6 +25,7 @@ func TestHandler_GetMetrics_Success(t *testing.T) {
 	handler := NewHandler(metricsService)
 
 	router := gin.New()
+	router.Use(RequestID(), Problems())
 	router.GET("/", handler.GetMetrics)
 
 	// Act
@@ -59,6 +60,7 @@ func TestHandler_GetMetrics_Success_AuthorWithNoBooks(t *testing.T) {
 	handler := NewHandler(metricsService)
 
 	router := gin.New()
+	router.Use(RequestID(), Problems())
 	router.GET("/", handler.GetMetrics)
 
 	// Act
@@ -88,6 +90,7 @@ func TestHandler_GetMetrics_Success_NoAuthorParameter(t *testing.T) {
 	handler := NewHandler(metricsService)
 
 	router := gin.New()
+	router.Use(RequestID(), Problems())
 	router.GET("/", handler.GetMetrics)
 
 	// Act
@@ -117,6 +120,7 @@ func TestHandler_GetMetrics_ServiceFailure(t *testing.T) {
 	handler := NewHandler(metricsService)
 
 	router := gin.New()
+	router.Use(RequestID(), Problems())
 	router.GET("/", handler.GetMetrics)
 
 	// Act
@@ -127,10 +131,192 @@ func TestHandler_GetMetrics_ServiceFailure(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusBadGateway, w.Code)
 
-	var response map[string]string
+	assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+
+	var response Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "error fetching books from external service", response["error"])
+	assert.Equal(t, "upstream_failure", response.Code)
+	assert.Equal(t, "error fetching books from external service", response.Detail)
+	assert.Equal(t, w.Header().Get(RequestIDHeader), response.RequestID)
+}
+
+func TestHandler_GetMetrics_CheapestTiesAndTopN(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := mockImpls.NewMockBooksRepositories()
+	metricsService := services.NewMetricsService(mockRepo)
+	handler := NewHandler(metricsService)
+
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.GET("/", handler.GetMetrics)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/?cheapest=ties&top_n=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result services.MetricsResult
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "The Go Programming Language", result.CheapestBook)
+	assert.Len(t, result.CheapestBooks, 1)
+	assert.Equal(t, "The Go Programming Language", result.TopCheapest[0].Name)
+	assert.Equal(t, "The Pragmatic Programmer", result.TopCheapest[1].Name)
+	assert.Equal(t, "Clean Code", result.TopSellers[0].Name)
+	assert.Len(t, result.TopSellers, 2)
+}
+
+func TestHandler_GetMetrics_CheapestBookObject(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := mockImpls.NewMockBooksRepositories()
+	metricsService := services.NewMetricsService(mockRepo)
+	handler := NewHandler(metricsService)
+
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.GET("/", handler.GetMetrics)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/?cheapest=book", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]any
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "The Go Programming Language", response["cheapest_book"])
+	assert.Equal(t, map[string]any{
+		"id": float64(1), "name": "The Go Programming Language", "author": "Alan Donovan",
+		"units_sold": float64(5000), "price": float64(40), "isbn": "978-0134190440",
+	}, response["cheapest_book_detail"])
+	assert.NotContains(t, response, "cheapest_books")
+	assert.NotContains(t, response, "top_sellers")
+}
+
+func TestHandler_GetMetrics_InvalidOptions(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := mockImpls.NewMockBooksRepositories()
+	metricsService := services.NewMetricsService(mockRepo)
+	handler := NewHandler(metricsService)
+
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.GET("/", handler.GetMetrics)
+
+	for _, target := range []string{"/?cheapest=all", "/?top_n=-1", "/?top_n=abc"} {
+		// Act
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestHandler_GetDistribution_Success(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := mockImpls.NewMockBooksRepositories()
+	metricsService := services.NewMetricsService(mockRepo)
+	handler := NewHandler(metricsService)
+
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.GET("/metrics/distribution", handler.GetDistribution)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/metrics/distribution?field=price&buckets=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result services.DistributionResult
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+
+	// Prices: 40, 50, 45
+	assert.Equal(t, 3, result.Count)
+	assert.Equal(t, float64(45), result.Median)
+	assert.Equal(t, []services.HistogramBucket{
+		{Lower: 40, Upper: 45, Count: 1},
+		{Lower: 45, Upper: 50, Count: 2},
+	}, result.Buckets)
+}
+
+func TestHandler_GetDistribution_CustomEdges(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := mockImpls.NewMockBooksRepositories()
+	metricsService := services.NewMetricsService(mockRepo)
+	handler := NewHandler(metricsService)
+
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.GET("/metrics/distribution", handler.GetDistribution)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/metrics/distribution?field=units_sold&edges=0,10000,20000", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result services.DistributionResult
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, services.BucketsCustom, result.Mode)
+	assert.Equal(t, []services.HistogramBucket{
+		{Lower: 0, Upper: 10000, Count: 1},
+		{Lower: 10000, Upper: 20000, Count: 2},
+	}, result.Buckets)
+}
+
+func TestHandler_GetDistribution_InvalidRequest(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := mockImpls.NewMockBooksRepositories()
+	metricsService := services.NewMetricsService(mockRepo)
+	handler := NewHandler(metricsService)
+
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.GET("/metrics/distribution", handler.GetDistribution)
+
+	for _, target := range []string{
+		"/metrics/distribution?field=isbn",
+		"/metrics/distribution?field=price&buckets=abc",
+		"/metrics/distribution?field=price&edges=1,x",
+		"/metrics/distribution?field=price&mode=fixed&edges=1,5",
+	} {
+		// Act
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
 }
 
 func TestNewHandler(t *testing.T) {
@@ -151,3 +337,13 @@ type mockErrorRepository struct{}
 func (m *mockErrorRepository) GetBooksProvider(ctx context.Context) ([]models.Book, error) {
 	return nil, errors.New("repository error")
 }
+
+// newTestRouter returns an engine with the request ID and problem middleware
+// every route runs behind in production, followed by middleware.
+func newTestRouter(middleware ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), Problems())
+	router.Use(middleware...)
+	return router
+}
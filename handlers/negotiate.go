@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable reports an Accept header that rules out every media type
+// a handler can produce.
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// negotiateMediaType returns the offer the Accept header ranks highest
+// (RFC 9110 section 12.5.1), using the most specific matching range for each
+// offer's q-value. Ties go to the earlier offer. An empty Accept header
+// selects the first offer; "" means nothing offered is acceptable.
+func negotiateMediaType(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type acceptRange struct {
+		mediaType   string
+		q           float64
+		specificity int
+	}
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		specificity := 2
+		switch {
+		case mediaType == "*/*":
+			specificity = 0
+		case strings.HasSuffix(mediaType, "/*"):
+			specificity = 1
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, specificity: specificity})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		major, _, _ := strings.Cut(offer, "/")
+		for _, r := range ranges {
+			if r.mediaType != offer && r.mediaType != major+"/*" && r.mediaType != "*/*" {
+				continue
+			}
+			if r.specificity > specificity {
+				q, specificity = r.q, r.specificity
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", MediaTypeJSON},
+		{"*/*", MediaTypeJSON},
+		{"text/html", MediaTypeHTML},
+		{"text/*", MediaTypeHTML},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", MediaTypeHTML},
+		{"application/json;q=0.5, text/html;q=0.4", MediaTypeJSON},
+		{"text/html;q=0.9, application/json;q=0.9", MediaTypeJSON},
+		{"*/*;q=0.1, application/json;q=0", MediaTypeHTML},
+		{"image/png", ""},
+		{"text/html;q=bogus, application/json;q=0.2", MediaTypeJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			// Act
+			mediaType := negotiateMediaType(tt.accept, MediaTypeJSON, MediaTypeHTML)
+
+			// Assert
+			assert.Equal(t, tt.expected, mediaType)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPISpec is the OpenAPI 3.1 description of every route. Tests validate
+// real handler responses against it, so it must be updated with the handlers.
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+func GetOpenAPISpec(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", OpenAPISpec)
+}
@@ -0,0 +1,420 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/auth"
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/handlers"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/server"
+	"educabot.com/bookshop/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type openAPIDocument map[string]any
+
+func loadOpenAPISpec(t *testing.T) openAPIDocument {
+	t.Helper()
+
+	var spec openAPIDocument
+	if err := json.Unmarshal(handlers.OpenAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return spec
+}
+
+// resolve follows a local JSON pointer such as "#/components/schemas/Book".
+func (spec openAPIDocument) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node any = map[string]any(spec)
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+		if node, ok = object[part]; !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	object, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("$ref %q is not an object", ref)
+	}
+	return object, nil
+}
+
+func (spec openAPIDocument) deref(node map[string]any) (map[string]any, error) {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node, nil
+		}
+		resolved, err := spec.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		node = resolved
+	}
+}
+
+// responseSchema returns the schema documented for a status code and media
+// type of an operation.
+func (spec openAPIDocument) responseSchema(method, path string, status int, mediaType string) (map[string]any, error) {
+	paths, _ := spec["paths"].(map[string]any)
+	pathItem, ok := paths[path].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("path %s is not documented", path)
+	}
+	pathItem, err := spec.deref(pathItem)
+	if err != nil {
+		return nil, err
+	}
+	operation, ok := pathItem[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s %s is not documented", method, path)
+	}
+	responses, _ := operation["responses"].(map[string]any)
+	response, ok := responses[fmt.Sprint(status)].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s %s does not document status %d", method, path, status)
+	}
+	if response, err = spec.deref(response); err != nil {
+		return nil, err
+	}
+	content, _ := response["content"].(map[string]any)
+	media, ok := content[mediaType].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s %s %d does not document %s", method, path, status, mediaType)
+	}
+	schema, _ := media["schema"].(map[string]any)
+	return schema, nil
+}
+
+// validate checks value against the subset of JSON Schema used by
+// openapi.json: $ref, anyOf, type, enum, properties, required,
+// additionalProperties, items and minimum.
+func (spec openAPIDocument) validate(schema map[string]any, value any, at string) []string {
+	schema, err := spec.deref(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", at, err)}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			if len(spec.validate(option.(map[string]any), value, at)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: matches none of anyOf", at)}
+	}
+
+	if expected, ok := schema["type"].(string); ok && !jsonTypeIs(value, expected) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", at, expected, jsonTypeOf(value))}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+			}
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, enum)}
+		}
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if number, ok := value.(float64); ok && number < minimum {
+			return []string{fmt.Sprintf("%s: %v is below the minimum %v", at, number, minimum)}
+		}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required property %q", at, name))
+			}
+		}
+		for name, property := range value {
+			propertySchema, ok := properties[name].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					problems = append(problems, fmt.Sprintf("%s: undocumented property %q", at, name))
+				}
+				continue
+			}
+			problems = append(problems, spec.validate(propertySchema, property, at+"."+name)...)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				problems = append(problems, spec.validate(items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func jsonTypeIs(value any, expected string) bool {
+	if expected == "integer" {
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	}
+	return jsonTypeOf(value) == expected
+}
+
+func jsonTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+var ginParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+const (
+	specTestAPIKey      = "spec-test-key"
+	specTestUnscopedKey = "spec-test-unscoped-key"
+)
+
+// newSpecTestRouter builds the production router on top of repository.
+// specTestAPIKey holds metrics:read; specTestUnscopedKey holds no scopes.
+func newSpecTestRouter(t *testing.T, repository repositories.BooksRepository) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := json.Marshal([]auth.APIKey{
+		{Name: "spec", SHA256: auth.HashAPIKey(specTestAPIKey), Scopes: []string{auth.ScopeMetricsRead}},
+		{Name: "unscoped", SHA256: auth.HashAPIKey(specTestUnscopedKey), Scopes: []string{}},
+	})
+	if !assert.NoError(t, err) || !assert.NoError(t, os.WriteFile(keysFile, keys, 0o600)) {
+		t.FailNow()
+	}
+	cfg := config.Default()
+	cfg.RateLimit.Rate = 0
+	cfg.Auth.APIKeysFile = keysFile
+	return server.NewRouter(cfg, repository)
+}
+
+func TestOpenAPISpec_RefsResolve(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+
+	// Act
+	var problems []string
+	var walk func(node any)
+	walk = func(node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			if ref, ok := node["$ref"].(string); ok {
+				if _, err := spec.resolve(ref); err != nil {
+					problems = append(problems, err.Error())
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(map[string]any(spec))
+
+	// Assert
+	assert.Equal(t, "3.1.0", spec["openapi"])
+	assert.Empty(t, problems)
+}
+
+func TestOpenAPISpec_ServedAsJSON(t *testing.T) {
+	// Arrange
+	router := newSpecTestRouter(t, mockImpls.NewMockBooksRepositories())
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(handlers.OpenAPISpec), w.Body.String())
+}
+
+func TestOpenAPISpec_ResponsesMatchHandlers(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	working := newSpecTestRouter(t, mockImpls.NewMockBooksRepositories())
+	failing := newSpecTestRouter(t, &testutil.StaticBooksRepository{Err: errors.New("repository error")})
+
+	tests := []struct {
+		route  string
+		target string
+		accept string
+		apiKey string
+		failed bool
+		status int
+	}{
+		{route: "/", target: "/?author=Robert+C.+Martin", status: http.StatusOK},
+		{route: "/", target: "/?cheapest=book&top_n=2", status: http.StatusOK},
+		{route: "/", target: "/?cheapest=ties", accept: handlers.MediaTypeV1, status: http.StatusOK},
+		{route: "/", target: "/?top_n=1", accept: handlers.MediaTypeV2, status: http.StatusOK},
+		{route: "/", target: "/?cheapest=bogus", status: http.StatusBadRequest},
+		{route: "/", target: "/", failed: true, status: http.StatusBadGateway},
+		{route: "/v1/metrics", target: "/v1/metrics?author=Robert+C.+Martin", status: http.StatusOK},
+		{route: "/v1/metrics", target: "/v1/metrics", failed: true, status: http.StatusBadGateway},
+		{route: "/v2/metrics", target: "/v2/metrics?top_n=2", status: http.StatusOK},
+		{route: "/v2/metrics", target: "/v2/metrics?top_n=-1", status: http.StatusBadRequest},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=price&buckets=2", apiKey: specTestAPIKey, status: http.StatusOK},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=units_sold&edges=0,10000,20000", apiKey: specTestAPIKey, status: http.StatusOK},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=weight", apiKey: specTestAPIKey, status: http.StatusBadRequest},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=price", failed: true, apiKey: specTestAPIKey, status: http.StatusBadGateway},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=price", status: http.StatusUnauthorized},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=price", apiKey: "wrong", status: http.StatusUnauthorized},
+		{route: "/metrics/distribution", target: "/metrics/distribution?field=price", apiKey: specTestUnscopedKey, status: http.StatusForbidden},
+		{route: "/books/isbn/duplicates", target: "/books/isbn/duplicates", status: http.StatusOK},
+		{route: "/books/isbn/:isbn", target: "/books/isbn/9780132350884", status: http.StatusOK},
+		{route: "/books/isbn/:isbn", target: "/books/isbn/123", status: http.StatusBadRequest},
+		{route: "/books/isbn/:isbn", target: "/books/isbn/9780306406157", status: http.StatusNotFound},
+		{route: "/books/:id/similar", target: "/books/1/similar?limit=2", status: http.StatusOK},
+		{route: "/books/:id/similar", target: "/books/999/similar", status: http.StatusNotFound},
+		{route: "/books/:id/similar", target: "/books/abc/similar", status: http.StatusBadRequest},
+		{route: "/search", target: "/search?q=pragmatic", status: http.StatusOK},
+		{route: "/search", target: "/search?q=", status: http.StatusBadRequest},
+		{route: "/search", target: "/search?q=go", failed: true, status: http.StatusBadGateway},
+		{route: "/suggest", target: "/suggest?field=author&prefix=mar", status: http.StatusOK},
+		{route: "/suggest", target: "/suggest?field=isbn&prefix=mar", status: http.StatusBadRequest},
+		{route: "/reports/summary", target: "/reports/summary?author=Alan+Donovan&top_n=2", apiKey: specTestAPIKey, status: http.StatusOK},
+		{route: "/reports/summary", target: "/reports/summary?format=pdf", apiKey: specTestAPIKey, status: http.StatusBadRequest},
+		{route: "/reports/summary", target: "/reports/summary", failed: true, apiKey: specTestAPIKey, status: http.StatusBadGateway},
+		{route: "/reports/summary", target: "/reports/summary?top_n=1", status: http.StatusUnauthorized},
+		{route: "/reports/summary", target: "/reports/summary?top_n=1", apiKey: specTestUnscopedKey, status: http.StatusForbidden},
+		{route: "/export/books", target: "/export/books?format=ndjson", apiKey: specTestAPIKey, status: http.StatusOK},
+		{route: "/export/books", target: "/export/books?format=pdf", apiKey: specTestAPIKey, status: http.StatusBadRequest},
+		{route: "/export/books", target: "/export/books", failed: true, apiKey: specTestAPIKey, status: http.StatusBadGateway},
+		{route: "/export/books", target: "/export/books?format=csv", status: http.StatusUnauthorized},
+		{route: "/export/books", target: "/export/books?format=csv", apiKey: specTestUnscopedKey, status: http.StatusForbidden},
+		{route: "/export/authors", target: "/export/authors", accept: handlers.MediaTypeNDJSON, apiKey: specTestAPIKey, status: http.StatusOK},
+		{route: "/export/authors", target: "/export/authors?format=ndjson", status: http.StatusUnauthorized},
+		{route: "/export/authors", target: "/export/authors?format=ndjson", apiKey: specTestUnscopedKey, status: http.StatusForbidden},
+		{route: "/v1/search", target: "/v1/search?q=code", status: http.StatusOK},
+		{route: "/v2/books/:id/similar", target: "/v2/books/2/similar", status: http.StatusOK},
+		{route: "/v1/reports/summary", target: "/v1/reports/summary", status: http.StatusUnauthorized},
+		{route: "/v2/export/books", target: "/v2/export/books", apiKey: specTestUnscopedKey, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			// Arrange
+			router := working
+			if tt.failed {
+				router = failing
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+			assert.NoError(t, err)
+			path := ginParam.ReplaceAllString(tt.route, "{$1}")
+			schema, err := spec.responseSchema(http.MethodGet, path, w.Code, mediaType)
+			if !assert.NoError(t, err) {
+				return
+			}
+			documents := [][]byte{w.Body.Bytes()}
+			if mediaType == handlers.MediaTypeNDJSON {
+				documents = bytes.SplitAfter(bytes.TrimSuffix(w.Body.Bytes(), []byte("\n")), []byte("\n"))
+			}
+			for _, document := range documents {
+				var body any
+				assert.NoError(t, json.Unmarshal(document, &body))
+				assert.Empty(t, spec.validate(schema, body, "$"))
+			}
+		})
+	}
+}
+
+func TestOpenAPISpec_DocumentsConditionalGET(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+	router := newSpecTestRouter(t, mockImpls.NewMockBooksRepositories())
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/search?q=go", nil))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/search?q=go", nil)
+	req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	for path, item := range spec["paths"].(map[string]any) {
+		operation, ok := item.(map[string]any)["get"].(map[string]any)
+		if !ok {
+			continue
+		}
+		assert.Contains(t, operation["responses"], "304", path)
+	}
+}
+
+func TestOpenAPISpec_ValidatorRejectsDrift(t *testing.T) {
+	// Arrange
+	spec := loadOpenAPISpec(t)
+	schema := map[string]any{"$ref": "#/components/schemas/MetricsV1Response"}
+
+	// Act
+	problems := spec.validate(schema, map[string]any{
+		"mean_units_sold": 1.5,
+		"cheapest_book":   "Clean Code",
+		"renamed_field":   1.0,
+	}, "$")
+
+	// Assert
+	assert.ElementsMatch(t, []string{
+		"$.mean_units_sold: expected integer, got number",
+		`$: missing required property "books_written_by_author"`,
+		`$: undocumented property "renamed_field"`,
+	}, problems)
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"educabot.com/bookshop/auth"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MediaTypeProblem = "application/problem+json"
+	RequestIDHeader  = "X-Request-ID"
+
+	requestIDKey        = "request_id"
+	maxRequestIDLength  = 128
+	problemTypeBase     = "urn:bookshop:problem:"
+	internalErrorDetail = "an unexpected error occurred"
+)
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for
+// programs; Title and Detail are for humans and may change.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// QueryError reports a malformed query string or path parameter.
+type QueryError struct {
+	Param string
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	if e.Param == "" {
+		return "invalid query parameters"
+	}
+	return fmt.Sprintf("invalid parameter %s: %v", e.Param, e.Err)
+}
+
+func (e *QueryError) Unwrap() error { return e.Err }
+
+type ProblemMapping struct {
+	Code   string
+	Status int
+	Title  string
+	Match  func(error) bool
+	// Detail builds the client-facing detail. When nil the error message is
+	// used; upstream errors set it so causes such as dial errors stay in logs.
+	Detail func(error) string
+}
+
+func isAny(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isType[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
+
+func fixedDetail(detail string) func(error) string {
+	return func(error) string { return detail }
+}
+
+func upstreamStatusDetail(err error) string {
+	var statusErr *repositories.UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Error()
+	}
+	return repositories.ErrUpstreamStatus.Error()
+}
+
+// ProblemMappings is checked in order, so specific upstream failures must
+// come before the generic ErrServiceUnavailable and ErrExternalServiceFailure.
+var ProblemMappings = []ProblemMapping{
+	{
+		Code:   "validation_failed",
+		Status: http.StatusBadRequest,
+		Title:  "Invalid request",
+		Match: func(err error) bool {
+			return isType[*QueryError](err) || isAny(
+				services.ErrInvalidMetricsOptions,
+				services.ErrInvalidDistribution,
+				services.ErrInvalidISBN,
+				services.ErrEmptyQuery,
+				services.ErrInvalidSuggestField,
+				services.ErrEmptyPrefix,
+			)(err)
+		},
+	},
+	{Code: "unauthenticated", Status: http.StatusUnauthorized, Title: "Authentication required", Match: isAny(auth.ErrUnauthenticated)},
+	{
+		Code:   "invalid_credentials",
+		Status: http.StatusUnauthorized,
+		Title:  "Invalid credentials",
+		Match:  isAny(auth.ErrInvalidCredentials),
+		Detail: fixedDetail(auth.ErrInvalidCredentials.Error()),
+	},
+	{Code: "insufficient_scope", Status: http.StatusForbidden, Title: "Forbidden", Match: isAny(auth.ErrInsufficientScope)},
+	{Code: "cross_origin_rejected", Status: http.StatusForbidden, Title: "Forbidden", Match: isAny(ErrCrossOriginRejected)},
+	{Code: "not_found", Status: http.StatusNotFound, Title: "Not found", Match: isAny(services.ErrBookNotFound, ErrAssetNotFound)},
+	{Code: "not_acceptable", Status: http.StatusNotAcceptable, Title: "Not acceptable", Match: isAny(ErrNotAcceptable)},
+	{Code: "rate_limited", Status: http.StatusTooManyRequests, Title: "Too many requests", Match: isAny(ErrRateLimited)},
+	{
+		Code:   "upstream_timeout",
+		Status: http.StatusGatewayTimeout,
+		Title:  "Upstream timed out",
+		Match:  isType[*repositories.UpstreamTimeoutError],
+		Detail: fixedDetail("external service timed out"),
+	},
+	{
+		Code:   "upstream_bad_status",
+		Status: http.StatusBadGateway,
+		Title:  "Upstream returned an error",
+		Match:  isAny(repositories.ErrUpstreamStatus),
+		Detail: upstreamStatusDetail,
+	},
+	{
+		Code:   "upstream_decode_failed",
+		Status: http.StatusBadGateway,
+		Title:  "Upstream returned an invalid response",
+		Match:  isAny(repositories.ErrUpstreamDecode),
+		Detail: fixedDetail(repositories.ErrUpstreamDecode.Error()),
+	},
+	{
+		Code:   "upstream_auth_failed",
+		Status: http.StatusBadGateway,
+		Title:  "Upstream authentication failed",
+		Match:  isAny(repositories.ErrUpstreamAuth),
+		Detail: fixedDetail(repositories.ErrUpstreamAuth.Error()),
+	},
+	{
+		Code:   "upstream_unavailable",
+		Status: http.StatusServiceUnavailable,
+		Title:  "Upstream unavailable",
+		Match:  isAny(repositories.ErrServiceUnavailable),
+		Detail: fixedDetail(repositories.ErrServiceUnavailable.Error()),
+	},
+	{
+		Code:   "upstream_failure",
+		Status: http.StatusBadGateway,
+		Title:  "Upstream failure",
+		Match:  isAny(services.ErrExternalServiceFailure),
+		Detail: fixedDetail(services.ErrExternalServiceFailure.Error()),
+	},
+}
+
+var internalErrorMapping = ProblemMapping{
+	Code:   "internal_error",
+	Status: http.StatusInternalServerError,
+	Title:  "Internal server error",
+	Detail: fixedDetail(internalErrorDetail),
+}
+
+// ProblemFor returns the first mapping matching err, or internal_error.
+func ProblemFor(err error) ProblemMapping {
+	for _, mapping := range ProblemMappings {
+		if mapping.Match(err) {
+			return mapping
+		}
+	}
+	return internalErrorMapping
+}
+
+func NewProblem(err error, instance, requestID string) Problem {
+	mapping := ProblemFor(err)
+	detail := err.Error()
+	if mapping.Detail != nil {
+		detail = mapping.Detail(err)
+	}
+	return Problem{
+		Type:      problemTypeBase + mapping.Code,
+		Title:     mapping.Title,
+		Status:    mapping.Status,
+		Detail:    detail,
+		Instance:  instance,
+		Code:      mapping.Code,
+		RequestID: requestID,
+	}
+}
+
+// upstreamLogAttrs adds one field per kind of upstream failure so logs can
+// be filtered without parsing error messages.
+func upstreamLogAttrs(err error) []any {
+	var attrs []any
+	var statusErr *repositories.UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		attrs = append(attrs, slog.Int("upstream_status", statusErr.Code))
+	}
+	var timeoutErr *repositories.UpstreamTimeoutError
+	if errors.As(err, &timeoutErr) {
+		attrs = append(attrs, slog.String("upstream_timeout", timeoutErr.Err.Error()))
+	}
+	var decodeErr *repositories.UpstreamDecodeError
+	if errors.As(err, &decodeErr) {
+		attrs = append(attrs, slog.String("upstream_decode_error", decodeErr.Err.Error()))
+	}
+	return attrs
+}
+
+// Problems renders the last error a handler attached with ctx.Error as
+// application/problem+json, unless the handler already wrote a response.
+func Problems() gin.HandlerFunc {
+	return ProblemsWithLogger(slog.Default())
+}
+
+// ProblemsWithLogger is Problems logging server-side failures, with their
+// full cause chain, to logger.
+func ProblemsWithLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		last := ctx.Errors.Last()
+		if last == nil || ctx.Writer.Written() {
+			return
+		}
+		problem := NewProblem(last.Err, ctx.Request.URL.Path, RequestIDFrom(ctx))
+		if problem.Status >= http.StatusInternalServerError {
+			attrs := append([]any{
+				slog.String("request_id", problem.RequestID),
+				slog.String("path", problem.Instance),
+				slog.Int("status", problem.Status),
+				slog.String("code", problem.Code),
+				slog.String("error", last.Err.Error()),
+			}, upstreamLogAttrs(last.Err)...)
+			logger.ErrorContext(ctx, "request failed", attrs...)
+		}
+		ctx.Header("Content-Type", MediaTypeProblem)
+		ctx.JSON(problem.Status, problem)
+	}
+}
+
+// RequestID propagates a well-formed X-Request-ID from the client or assigns
+// a new one, and echoes it on the response.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		ctx.Set(requestIDKey, id)
+		ctx.Header(RequestIDHeader, id)
+		ctx.Next()
+	}
+}
+
+func RequestIDFrom(ctx *gin.Context) string {
+	return ctx.GetString(requestIDKey)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"query error", &QueryError{Err: errors.New("bad")}, "validation_failed", http.StatusBadRequest},
+		{"wrapped query error", fmt.Errorf("binding: %w", &QueryError{Param: "id", Err: errors.New("bad")}), "validation_failed", http.StatusBadRequest},
+		{"metrics options", fmt.Errorf("%w: top_n", services.ErrInvalidMetricsOptions), "validation_failed", http.StatusBadRequest},
+		{"distribution", services.ErrInvalidDistribution, "validation_failed", http.StatusBadRequest},
+		{"isbn", services.ErrInvalidISBN, "validation_failed", http.StatusBadRequest},
+		{"empty query", services.ErrEmptyQuery, "validation_failed", http.StatusBadRequest},
+		{"suggest field", services.ErrInvalidSuggestField, "validation_failed", http.StatusBadRequest},
+		{"empty prefix", services.ErrEmptyPrefix, "validation_failed", http.StatusBadRequest},
+		{"not found", fmt.Errorf("lookup: %w", services.ErrBookNotFound), "not_found", http.StatusNotFound},
+		{"not acceptable", fmt.Errorf("%w: json only", ErrNotAcceptable), "not_acceptable", http.StatusNotAcceptable},
+		{"upstream status", &repositories.UpstreamStatusError{Code: 500}, "upstream_bad_status", http.StatusBadGateway},
+		{"upstream status sentinel", fmt.Errorf("%w 500", repositories.ErrUpstreamStatus), "upstream_bad_status", http.StatusBadGateway},
+		{"upstream decode", &repositories.UpstreamDecodeError{Err: io.ErrUnexpectedEOF}, "upstream_decode_failed", http.StatusBadGateway},
+		{"upstream auth", &repositories.TokenError{Status: 401, Code: "invalid_client"}, "upstream_auth_failed", http.StatusBadGateway},
+		{"upstream timeout", &repositories.UpstreamTimeoutError{Err: context.DeadlineExceeded}, "upstream_timeout", http.StatusGatewayTimeout},
+		{"upstream unavailable", repositories.ErrServiceUnavailable, "upstream_unavailable", http.StatusServiceUnavailable},
+		{"upstream status behind service failure", fmt.Errorf("%w: %w", services.ErrExternalServiceFailure, &repositories.UpstreamStatusError{Code: 503}), "upstream_bad_status", http.StatusBadGateway},
+		{"upstream timeout behind service failure", fmt.Errorf("%w: %w", services.ErrExternalServiceFailure, &repositories.UpstreamTimeoutError{Err: context.DeadlineExceeded}), "upstream_timeout", http.StatusGatewayTimeout},
+		{"service failure", services.ErrExternalServiceFailure, "upstream_failure", http.StatusBadGateway},
+		{"unknown", errors.New("boom"), "internal_error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			mapping := ProblemFor(tt.err)
+
+			// Assert
+			assert.Equal(t, tt.code, mapping.Code)
+			assert.Equal(t, tt.status, mapping.Status)
+		})
+	}
+}
+
+func TestProblemMappings_CodesAreUnique(t *testing.T) {
+	// Arrange
+	seen := map[string]bool{internalErrorMapping.Code: true}
+
+	// Act & Assert
+	for _, mapping := range ProblemMappings {
+		assert.False(t, seen[mapping.Code], "duplicate code %s", mapping.Code)
+		assert.NotEmpty(t, mapping.Title)
+		seen[mapping.Code] = true
+	}
+}
+
+func TestNewProblem_HidesInternalErrorDetail(t *testing.T) {
+	// Act
+	problem := NewProblem(errors.New("dial tcp 10.0.0.1:5432: secret"), "/", "abc")
+
+	// Assert
+	assert.Equal(t, "urn:bookshop:problem:internal_error", problem.Type)
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+	assert.Equal(t, internalErrorDetail, problem.Detail)
+	assert.Equal(t, "abc", problem.RequestID)
+}
+
+func TestNewProblem_UpstreamDetailOmitsCause(t *testing.T) {
+	// Arrange
+	cause := errors.New("dial tcp 10.0.0.1:443: connection refused")
+
+	// Act
+	status := NewProblem(fmt.Errorf("%w: %w", services.ErrExternalServiceFailure, &repositories.UpstreamStatusError{Code: 503}), "/", "")
+	unavailable := NewProblem(fmt.Errorf("%w: %w", services.ErrExternalServiceFailure, fmt.Errorf("%w: %w", repositories.ErrServiceUnavailable, cause)), "/", "")
+
+	// Assert
+	assert.Equal(t, "external service returned status 503", status.Detail)
+	assert.Equal(t, "upstream_unavailable", unavailable.Code)
+	assert.Equal(t, "external service failure", unavailable.Detail)
+}
+
+func TestProblemsWithLogger_LogsUpstreamFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		field string
+		value any
+	}{
+		{"status", &repositories.UpstreamStatusError{Code: 503}, "upstream_status", float64(503)},
+		{"timeout", &repositories.UpstreamTimeoutError{Err: context.DeadlineExceeded}, "upstream_timeout", "context deadline exceeded"},
+		{"decode", &repositories.UpstreamDecodeError{Err: io.ErrUnexpectedEOF}, "upstream_decode_error", "unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var logs bytes.Buffer
+			router := gin.New()
+			router.Use(RequestID(), ProblemsWithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+			router.GET("/", func(ctx *gin.Context) {
+				ctx.Error(fmt.Errorf("%w: %w", services.ErrExternalServiceFailure, tt.err))
+			})
+
+			// Act
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, "req-1")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			// Assert
+			var entry map[string]any
+			assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+			assert.Equal(t, "req-1", entry["request_id"])
+			assert.Equal(t, tt.value, entry[tt.field])
+			assert.Contains(t, entry["error"], tt.err.Error())
+		})
+	}
+}
+
+func newProblemTestRouter(err error) *gin.Engine {
+	router := newTestRouter()
+	router.GET("/fail", func(ctx *gin.Context) { ctx.Error(err) })
+	router.GET("/written", func(ctx *gin.Context) {
+		ctx.Error(err)
+		ctx.String(http.StatusTeapot, "handled")
+	})
+	return router
+}
+
+func TestProblems_RendersProblemJSON(t *testing.T) {
+	// Arrange
+	router := newProblemTestRouter(fmt.Errorf("%w: top_n", services.ErrInvalidMetricsOptions))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+	assert.Equal(t, "req-123", w.Header().Get(RequestIDHeader))
+
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, Problem{
+		Type:      "urn:bookshop:problem:validation_failed",
+		Title:     "Invalid request",
+		Status:    http.StatusBadRequest,
+		Detail:    "invalid metrics options: top_n",
+		Instance:  "/fail",
+		Code:      "validation_failed",
+		RequestID: "req-123",
+	}, problem)
+}
+
+func TestProblems_LeavesWrittenResponsesAlone(t *testing.T) {
+	// Arrange
+	router := newProblemTestRouter(errors.New("boom"))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/written", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "handled", w.Body.String())
+}
+
+func TestRequestID_GeneratesWhenMissingOrInvalid(t *testing.T) {
+	// Arrange
+	router := newProblemTestRouter(errors.New("boom"))
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("a", maxRequestIDLength+1)} {
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		if incoming != "" {
+			req.Header.Set(RequestIDHeader, incoming)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		id := w.Header().Get(RequestIDHeader)
+		assert.Len(t, id, 32)
+		assert.NotEqual(t, incoming, id)
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit is a token bucket: Burst tokens at most, refilled at Rate tokens
+// per second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type BucketState struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token, zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// BucketStore keeps one token bucket per key. Implementations must be safe
+// for concurrent use.
+type BucketStore interface {
+	Take(key string, limit RateLimit, now time.Time) BucketState
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryBucketStore keeps buckets in memory. Buckets idle for longer than
+// idleTTL are evicted; a missing bucket is the same as a full one, so idleTTL
+// should be at least Burst/Rate.
+type MemoryBucketStore struct {
+	idleTTL time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewMemoryBucketStore(idleTTL time.Duration) *MemoryBucketStore {
+	return &MemoryBucketStore{idleTTL: idleTTL, buckets: make(map[string]*tokenBucket)}
+}
+
+func (s *MemoryBucketStore) Take(key string, limit RateLimit, now time.Time) BucketState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	burst := float64(limit.Burst)
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		s.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(burst, bucket.tokens+elapsed*limit.Rate)
+	}
+	bucket.last = now
+
+	state := BucketState{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		state.Allowed = true
+	} else {
+		state.RetryAfter = secondsToDuration((1 - bucket.tokens) / limit.Rate)
+	}
+	state.Remaining = int(bucket.tokens)
+	state.Reset = secondsToDuration((burst - bucket.tokens) / limit.Rate)
+	return state
+}
+
+func (s *MemoryBucketStore) sweep(now time.Time) {
+	if s.idleTTL <= 0 || now.Sub(s.lastSweep) < s.idleTTL {
+		return
+	}
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.last) > s.idleTTL {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+func (s *MemoryBucketStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RateLimitKeyFunc picks the bucket a request draws from.
+type RateLimitKeyFunc func(ctx *gin.Context) string
+
+// KeyByIP uses gin's ClientIP, which only trusts X-Forwarded-For from the
+// proxies configured with SetTrustedProxies.
+func KeyByIP(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// KeyByPrincipal gives each caller authenticated by Identify its own bucket
+// and falls back to the client IP. Unknown credentials share their IP's
+// bucket, so inventing keys neither resets the limit nor adds buckets.
+func KeyByPrincipal(ctx *gin.Context) string {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return KeyByIP(ctx)
+	}
+	return "principal:" + string(principal.Method) + ":" + principal.Subject
+}
+
+// KeyByRoute shares one bucket between all clients of a route.
+func KeyByRoute(ctx *gin.Context) string {
+	route := ctx.FullPath()
+	if route == "" {
+		route = ctx.Request.URL.Path
+	}
+	return "route:" + ctx.Request.Method + " " + route
+}
+
+type RateLimitOptions struct {
+	Limit RateLimit
+	Key   RateLimitKeyFunc
+	Store BucketStore
+	Now   func() time.Time
+}
+
+// RateLimiter rejects requests with 429 once their bucket is empty and
+// reports the bucket state in RateLimit-* headers on every response.
+func RateLimiter(options RateLimitOptions) gin.HandlerFunc {
+	if options.Key == nil {
+		options.Key = KeyByIP
+	}
+	if options.Store == nil {
+		options.Store = NewMemoryBucketStore(10 * time.Minute)
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	limit := strconv.Itoa(options.Limit.Burst)
+	policy := limit + ";w=" + strconv.Itoa(int(math.Ceil(float64(options.Limit.Burst)/options.Limit.Rate)))
+
+	return func(ctx *gin.Context) {
+		state := options.Store.Take(options.Key(ctx), options.Limit, options.Now())
+		ctx.Header("RateLimit-Policy", policy)
+		ctx.Header("RateLimit-Limit", limit)
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(state.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(state.Reset)))
+		if !state.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(state.RetryAfter))))
+			ctx.Error(ErrRateLimited)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newRateLimitTestRouter(options RateLimitOptions) *gin.Engine {
+	router := newTestRouter(RateLimiter(options))
+	router.GET("/", func(ctx *gin.Context) { ctx.String(http.StatusOK, "ok") })
+	router.GET("/other", func(ctx *gin.Context) { ctx.String(http.StatusOK, "ok") })
+	return router
+}
+
+func get(router *gin.Engine, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMemoryBucketStore_RefillsOverTime(t *testing.T) {
+	// Arrange
+	store := NewMemoryBucketStore(time.Minute)
+	limit := RateLimit{Rate: 2, Burst: 2}
+	now := time.Unix(0, 0)
+
+	// Act
+	first := store.Take("a", limit, now)
+	second := store.Take("a", limit, now)
+	denied := store.Take("a", limit, now)
+	refilled := store.Take("a", limit, now.Add(500*time.Millisecond))
+
+	// Assert
+	assert.Equal(t, BucketState{Allowed: true, Remaining: 1, Reset: 500 * time.Millisecond}, first)
+	assert.Equal(t, BucketState{Allowed: true, Remaining: 0, Reset: time.Second}, second)
+	assert.Equal(t, BucketState{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: time.Second}, denied)
+	assert.True(t, refilled.Allowed)
+}
+
+func TestMemoryBucketStore_EvictsIdleKeys(t *testing.T) {
+	// Arrange
+	store := NewMemoryBucketStore(time.Minute)
+	limit := RateLimit{Rate: 1, Burst: 1}
+	now := time.Unix(0, 0)
+	store.Take("idle", limit, now)
+	store.Take("busy", limit, now.Add(50*time.Second))
+
+	// Act
+	store.Take("busy", limit, now.Add(90*time.Second))
+
+	// Assert
+	assert.Equal(t, 1, store.Len())
+	state := store.Take("idle", limit, now.Add(90*time.Second))
+	assert.True(t, state.Allowed)
+}
+
+func TestRateLimiter_ThrottlesWithHeadersAndProblem(t *testing.T) {
+	// Arrange
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	router := newRateLimitTestRouter(RateLimitOptions{Limit: RateLimit{Rate: 0.5, Burst: 2}, Now: clock.Now})
+
+	// Act
+	first := get(router, "/", nil)
+	get(router, "/", nil)
+	throttled := get(router, "/", nil)
+	clock.Advance(2 * time.Second)
+	recovered := get(router, "/", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2;w=4", first.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusTooManyRequests, throttled.Code)
+	assert.Equal(t, "0", throttled.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", throttled.Header().Get("Retry-After"))
+	assert.Equal(t, MediaTypeProblem, throttled.Header().Get("Content-Type"))
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(throttled.Body.Bytes(), &problem))
+	assert.Equal(t, "rate_limited", problem.Code)
+
+	assert.Equal(t, http.StatusOK, recovered.Code)
+}
+
+func TestRateLimiter_KeyByIPIgnoresUntrustedForwardedFor(t *testing.T) {
+	// Arrange
+	router := newRateLimitTestRouter(RateLimitOptions{Limit: RateLimit{Rate: 1, Burst: 1}, Key: KeyByIP})
+	router.SetTrustedProxies(nil)
+
+	// Act
+	get(router, "/", map[string]string{"X-Forwarded-For": "203.0.113.1"})
+	spoofed := get(router, "/", map[string]string{"X-Forwarded-For": "203.0.113.2"})
+
+	// Assert
+	assert.Equal(t, http.StatusTooManyRequests, spoofed.Code)
+}
+
+func TestRateLimiter_KeyByPrincipal(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{Name: "alpha", SHA256: auth.HashAPIKey("alpha-key")},
+		{Name: "beta", SHA256: auth.HashAPIKey("beta-key")},
+	})
+	assert.NoError(t, err)
+	store := NewMemoryBucketStore(time.Minute)
+	router := newTestRouter(Identify(&auth.Authenticator{APIKeys: keys}))
+	router.Use(RateLimiter(RateLimitOptions{Limit: RateLimit{Rate: 1, Burst: 1}, Key: KeyByPrincipal, Store: store}))
+	router.GET("/", func(ctx *gin.Context) { ctx.String(http.StatusOK, "ok") })
+
+	// Act
+	alpha := get(router, "/", map[string]string{auth.APIKeyHeader: "alpha-key"})
+	beta := get(router, "/", map[string]string{auth.APIKeyHeader: "beta-key"})
+	invented := get(router, "/", map[string]string{auth.APIKeyHeader: "invented-1"})
+	reinvented := get(router, "/", map[string]string{auth.APIKeyHeader: "invented-2"})
+	anonymous := get(router, "/", nil)
+	alphaAgain := get(router, "/", map[string]string{auth.APIKeyHeader: "alpha-key"})
+
+	// Assert
+	assert.Equal(t, http.StatusOK, alpha.Code)
+	assert.Equal(t, http.StatusOK, beta.Code)
+	assert.Equal(t, http.StatusOK, invented.Code)
+	assert.Equal(t, http.StatusTooManyRequests, reinvented.Code)
+	assert.Equal(t, http.StatusTooManyRequests, anonymous.Code)
+	assert.Equal(t, http.StatusTooManyRequests, alphaAgain.Code)
+	assert.Equal(t, 3, store.Len())
+}
+
+func TestRateLimiter_KeyByRoute(t *testing.T) {
+	// Arrange
+	router := newRateLimitTestRouter(RateLimitOptions{Limit: RateLimit{Rate: 1, Burst: 1}, Key: KeyByRoute})
+
+	// Act
+	root := get(router, "/", nil)
+	other := get(router, "/other", nil)
+	rootAgain := get(router, "/", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, root.Code)
+	assert.Equal(t, http.StatusOK, other.Code)
+	assert.Equal(t, http.StatusTooManyRequests, rootAgain.Code)
+}
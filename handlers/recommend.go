@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSimilarLimit = 10
+
+type RecommendationsHandler struct {
+	recommender *services.Recommender
+}
+
+type SimilarBooksRequest struct {
+	Limit int `form:"limit" binding:"min=0,max=50"`
+}
+
+func NewRecommendationsHandler(recommender *services.Recommender) *RecommendationsHandler {
+	return &RecommendationsHandler{recommender: recommender}
+}
+
+func (h *RecommendationsHandler) GetSimilarBooks(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 0)
+	if err != nil {
+		ctx.Error(&QueryError{Param: "id", Err: errors.New("must be a positive integer")})
+		return
+	}
+
+	var query SimilarBooksRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSimilarLimit
+	}
+
+	recommendations, err := h.recommender.Similar(ctx, uint(id), query.Limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recommendations": recommendations})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendationsHandler_GetSimilarBooks_Success(t *testing.T) {
+	// Arrange
+	recommender := services.NewRecommender(mockImpls.NewMockBooksRepositories(), services.DefaultRecommenderOptions())
+	router := newTestRouter()
+	router.GET("/books/:id/similar", NewRecommendationsHandler(recommender).GetSimilarBooks)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/2/similar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Recommendations []services.Recommendation `json:"recommendations"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Recommendations, 2)
+	assert.Equal(t, "The Pragmatic Programmer", response.Recommendations[0].Book.Name)
+	assert.NotEmpty(t, response.Recommendations[0].Reasons)
+}
+
+func TestRecommendationsHandler_GetSimilarBooks_InvalidID(t *testing.T) {
+	// Arrange
+	recommender := services.NewRecommender(mockImpls.NewMockBooksRepositories(), services.DefaultRecommenderOptions())
+	router := newTestRouter()
+	router.GET("/books/:id/similar", NewRecommendationsHandler(recommender).GetSimilarBooks)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/abc/similar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRecommendationsHandler_GetSimilarBooks_NotFound(t *testing.T) {
+	// Arrange
+	recommender := services.NewRecommender(mockImpls.NewMockBooksRepositories(), services.DefaultRecommenderOptions())
+	router := newTestRouter()
+	router.GET("/books/:id/similar", NewRecommendationsHandler(recommender).GetSimilarBooks)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/42/similar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRecommendationsHandler_GetSimilarBooks_ServiceFailure(t *testing.T) {
+	// Arrange
+	recommender := services.NewRecommender(&mockErrorRepository{}, services.DefaultRecommenderOptions())
+	router := newTestRouter()
+	router.GET("/books/:id/similar", NewRecommendationsHandler(recommender).GetSimilarBooks)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/books/1/similar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MediaTypeHTML = "text/html"
+	MediaTypeJSON = "application/json"
+
+	summaryTemplate = "summary.html.tmpl"
+	// reportContentSecurityPolicy allows the template's inline styles and
+	// nothing else.
+	reportContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'"
+)
+
+// defaultReportTemplates are compiled into the binary; LoadReportTemplates
+// lets a directory override them file by file.
+//
+//go:embed templates/*.tmpl
+var defaultReportTemplates embed.FS
+
+var reportFuncs = template.FuncMap{
+	"number":  formatNumber,
+	"price":   func(price uint) string { return "$" + formatNumber(price) },
+	"decimal": formatDecimal,
+	"date":    func(t time.Time) string { return t.Format("2 January 2006, 15:04 MST") },
+}
+
+// formatNumber adds thousands separators.
+func formatNumber(n uint) string {
+	digits := strconv.FormatUint(uint64(n), 10)
+	var buf bytes.Buffer
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteRune(digit)
+	}
+	return buf.String()
+}
+
+// formatDecimal rounds a non-negative value to cents with thousands
+// separators.
+func formatDecimal(value float64) string {
+	whole, cents, _ := strings.Cut(strconv.FormatFloat(value, 'f', 2, 64), ".")
+	n, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return whole + "." + cents
+	}
+	return formatNumber(uint(n)) + "." + cents
+}
+
+// LoadReportTemplates parses the embedded report templates and then every
+// *.tmpl file in dir, so a file named like an embedded one replaces it. An
+// empty dir keeps the embedded templates.
+func LoadReportTemplates(dir string) (*template.Template, error) {
+	embedded, err := fs.Sub(defaultReportTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	templates, err := template.New("reports").Funcs(reportFuncs).ParseFS(embedded, "*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return templates, nil
+	}
+
+	overrides := os.DirFS(dir)
+	matches, err := fs.Glob(overrides, "*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no *.tmpl files in %s", dir)
+	}
+	return templates.ParseFS(overrides, matches...)
+}
+
+type ReportsHandler struct {
+	service   *services.ReportsService
+	templates *template.Template
+}
+
+type GetSummaryReportRequest struct {
+	Author string `form:"author"`
+	TopN   int    `form:"top_n"`
+	Format string `form:"format"`
+}
+
+func NewReportsHandler(service *services.ReportsService, templates *template.Template) *ReportsHandler {
+	return &ReportsHandler{service: service, templates: templates}
+}
+
+// GetSummary serves the summary report as HTML or JSON. ?format= wins over
+// the Accept header; without either the report is JSON. An Accept header
+// that allows neither gets 406.
+func (h *ReportsHandler) GetSummary(ctx *gin.Context) {
+	var query GetSummaryReportRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+
+	var mediaType string
+	switch query.Format {
+	case "":
+		ctx.Header("Vary", "Accept")
+		mediaType = negotiateMediaType(ctx.GetHeader("Accept"), MediaTypeJSON, MediaTypeHTML)
+		if mediaType == "" {
+			ctx.Error(fmt.Errorf("%w: the report is available as %s or %s", ErrNotAcceptable, MediaTypeJSON, MediaTypeHTML))
+			return
+		}
+	case "json":
+		mediaType = MediaTypeJSON
+	case "html":
+		mediaType = MediaTypeHTML
+	default:
+		ctx.Error(&QueryError{Param: "format", Err: errors.New("must be json or html")})
+		return
+	}
+
+	report, err := h.service.Summary(ctx, services.ReportOptions{Author: query.Author, TopN: query.TopN})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if mediaType != MediaTypeHTML {
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.templates.ExecuteTemplate(&buf, summaryTemplate, report); err != nil {
+		ctx.Error(fmt.Errorf("render %s: %w", summaryTemplate, err))
+		return
+	}
+	ctx.Header("Content-Security-Policy", reportContentSecurityPolicy)
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+var reportTestTime = time.Date(2026, time.March, 1, 9, 30, 0, 0, time.UTC)
+
+// newReportsTestHandler reports on repository at reportTestTime, rendering
+// with the templates in templatesDir.
+func newReportsTestHandler(t *testing.T, repository repositories.BooksRepository, templatesDir string) *ReportsHandler {
+	t.Helper()
+	service := services.NewReportsService(repository)
+	service.Now = func() time.Time { return reportTestTime }
+	templates, err := LoadReportTemplates(templatesDir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return NewReportsHandler(service, templates)
+}
+
+// assertGolden compares got with testdata/reports/name; run the tests with
+// -update to accept new output.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "reports", name)
+	if *updateGolden {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(want), string(got))
+	}
+}
+
+func TestReportsHandler_GoldenHTML(t *testing.T) {
+	escaping := &testutil.StaticBooksRepository{Books: []models.Book{
+		{ID: 1, Name: "<script>alert(1)</script>", Author: "O'Brien & Sons", UnitsSold: 1234567, Price: 1999},
+		{ID: 2, Name: "Second", Author: "O'Brien & Sons", UnitsSold: 10, Price: 5},
+	}}
+
+	tests := []struct {
+		golden     string
+		repository repositories.BooksRepository
+		target     string
+	}{
+		{"summary.html", mockImpls.NewMockBooksRepositories(), "/reports/summary?format=html&author=Robert+C.+Martin"},
+		{"summary_escaped.html", escaping, "/reports/summary?format=html"},
+		{"summary_empty.html", &testutil.StaticBooksRepository{}, "/reports/summary?format=html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			// Arrange
+			router := newTestRouter()
+			router.GET("/reports/summary", newReportsTestHandler(t, tt.repository, "").GetSummary)
+
+			// Act
+			w := get(router, tt.target, nil)
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, reportContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+			assertGolden(t, tt.golden, w.Body.Bytes())
+		})
+	}
+}
+
+func TestReportsHandler_Negotiation(t *testing.T) {
+	router := newTestRouter()
+	router.GET("/reports/summary", newReportsTestHandler(t, mockImpls.NewMockBooksRepositories(), "").GetSummary)
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+		vary        string
+	}{
+		{"default", "/reports/summary", "", "application/json; charset=utf-8", "Accept"},
+		{"browser", "/reports/summary", "text/html,application/xhtml+xml,*/*;q=0.8", "text/html; charset=utf-8", "Accept"},
+		{"format wins", "/reports/summary?format=json", "text/html", "application/json; charset=utf-8", ""},
+		{"format html", "/reports/summary?format=html", "application/json", "text/html; charset=utf-8", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			w := get(router, tt.target, map[string]string{"Accept": tt.accept})
+
+			// Assert
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.vary, w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestReportsHandler_NotAcceptable(t *testing.T) {
+	// Arrange
+	router := newTestRouter()
+	router.GET("/reports/summary", newReportsTestHandler(t, mockImpls.NewMockBooksRepositories(), "").GetSummary)
+
+	// Act
+	w := get(router, "/reports/summary", map[string]string{"Accept": "application/pdf"})
+
+	// Assert
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "not_acceptable", problem.Code)
+	assert.Equal(t, "not acceptable: the report is available as application/json or text/html", problem.Detail)
+}
+
+func TestReportsHandler_JSON(t *testing.T) {
+	// Arrange
+	router := newTestRouter()
+	router.GET("/reports/summary", newReportsTestHandler(t, mockImpls.NewMockBooksRepositories(), "").GetSummary)
+
+	// Act
+	w := get(router, "/reports/summary?top_n=1", nil)
+
+	// Assert
+	var report services.SummaryReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, reportTestTime, report.GeneratedAt)
+	assert.Equal(t, uint(11000), report.Metrics.MeanUnitsSold)
+	assert.Len(t, report.Metrics.TopSellers, 1)
+	assert.Equal(t, "Robert C. Martin", report.Authors[0].Author)
+}
+
+func TestReportsHandler_InvalidFormat(t *testing.T) {
+	// Arrange
+	router := newTestRouter()
+	router.GET("/reports/summary", newReportsTestHandler(t, mockImpls.NewMockBooksRepositories(), "").GetSummary)
+
+	// Act
+	w := get(router, "/reports/summary?format=pdf", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid parameter format")
+}
+
+func TestLoadReportTemplates_Overrides(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	override := `<h1>Custom report</h1><p>{{number .Metrics.MeanUnitsSold}} units, {{len .Authors}} authors</p>`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, summaryTemplate), []byte(override), 0o644))
+	router := newTestRouter()
+	router.GET("/reports/summary", newReportsTestHandler(t, mockImpls.NewMockBooksRepositories(), dir).GetSummary)
+
+	// Act
+	w := get(router, "/reports/summary?format=html", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<h1>Custom report</h1><p>11,000 units, 3 authors</p>", w.Body.String())
+}
+
+func TestLoadReportTemplates_Errors(t *testing.T) {
+	// Arrange
+	broken := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(broken, summaryTemplate), []byte(`{{if}}`), 0o644))
+
+	// Act
+	_, missingErr := LoadReportTemplates(filepath.Join(t.TempDir(), "missing"))
+	_, emptyErr := LoadReportTemplates(t.TempDir())
+	_, brokenErr := LoadReportTemplates(broken)
+
+	// Assert
+	assert.Error(t, missingErr)
+	assert.Error(t, emptyErr)
+	assert.Error(t, brokenErr)
+}
+
+func TestFormatNumber(t *testing.T) {
+	assert.Equal(t, "0", formatNumber(0))
+	assert.Equal(t, "999", formatNumber(999))
+	assert.Equal(t, "1,000", formatNumber(1000))
+	assert.Equal(t, "1,234,567", formatNumber(1234567))
+	assert.Equal(t, "1,002.00", formatDecimal(1002))
+	assert.Equal(t, "12.35", formatDecimal(12.345678))
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSearchLimit = 20
+
+type SearchHandler struct {
+	service *services.SearchService
+}
+
+type SearchRequest struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit" binding:"min=0,max=100"`
+}
+
+func NewSearchHandler(service *services.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+func (h *SearchHandler) Search(ctx *gin.Context) {
+	var query SearchRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSearchLimit
+	}
+
+	result, err := h.service.Search(ctx, query.Query, query.Limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
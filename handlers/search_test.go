@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchHandler_Search_Success(t *testing.T) {
+	// Arrange
+	handler := NewSearchHandler(services.NewSearchService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/search", handler.Search)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/search?q=clean", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result services.SearchResult
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, "<mark>Clean</mark> Code", result.Hits[0].Highlights.Name)
+}
+
+func TestSearchHandler_Search_EmptyQuery(t *testing.T) {
+	// Arrange
+	handler := NewSearchHandler(services.NewSearchService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/search", handler.Search)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/search", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSearchHandler_Search_InvalidLimit(t *testing.T) {
+	// Arrange
+	handler := NewSearchHandler(services.NewSearchService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/search", handler.Search)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/search?q=go&limit=500", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSearchHandler_Search_ServiceFailure(t *testing.T) {
+	// Arrange
+	handler := NewSearchHandler(services.NewSearchService(&mockErrorRepository{}))
+	router := newTestRouter()
+	router.GET("/search", handler.Search)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/search?q=go", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSuggestLimit = 10
+
+type SuggestHandler struct {
+	service *services.SuggestService
+}
+
+type SuggestRequest struct {
+	Prefix string `form:"prefix"`
+	Field  string `form:"field"`
+	Limit  int    `form:"limit" binding:"min=0,max=50"`
+}
+
+func NewSuggestHandler(service *services.SuggestService) *SuggestHandler {
+	return &SuggestHandler{service: service}
+}
+
+func (h *SuggestHandler) Suggest(ctx *gin.Context) {
+	var query SuggestRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSuggestLimit
+	}
+
+	suggestions, err := h.service.Suggest(ctx, services.SuggestField(query.Field), query.Prefix, query.Limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestHandler_Suggest_Success(t *testing.T) {
+	// Arrange
+	handler := NewSuggestHandler(services.NewSuggestService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/suggest", handler.Suggest)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/suggest?prefix=rob&field=author", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"suggestions":[{"value":"Robert C. Martin","units_sold":15000}]}`, w.Body.String())
+}
+
+func TestSuggestHandler_Suggest_InvalidField(t *testing.T) {
+	// Arrange
+	handler := NewSuggestHandler(services.NewSuggestService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/suggest", handler.Suggest)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/suggest?prefix=rob&field=isbn", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSuggestHandler_Suggest_MissingPrefix(t *testing.T) {
+	// Arrange
+	handler := NewSuggestHandler(services.NewSuggestService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/suggest", handler.Suggest)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/suggest?field=title", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSuggestHandler_Suggest_ServiceFailure(t *testing.T) {
+	// Arrange
+	handler := NewSuggestHandler(services.NewSuggestService(&mockErrorRepository{}))
+	router := newTestRouter()
+	router.GET("/suggest", handler.Suggest)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/suggest?prefix=a&field=title", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrAssetNotFound = errors.New("asset not found")
+
+const (
+	uiIndex = "index.html"
+	// uiContentSecurityPolicy keeps the dashboard on its own origin: no CDN
+	// fonts, scripts or images.
+	uiContentSecurityPolicy = "default-src 'self'; img-src 'self' data:; object-src 'none'; base-uri 'none'; frame-ancestors 'none'"
+	immutableCacheControl   = "public, max-age=31536000, immutable"
+)
+
+type uiAsset struct {
+	body        []byte
+	contentType string
+}
+
+// UI serves a single-page dashboard. Every file next to index.html is
+// published under Prefix+"/assets/" with a content hash in its name, so
+// assets can be cached forever while index.html is revalidated.
+type UI struct {
+	Prefix string
+
+	index  []byte
+	assets map[string]uiAsset
+	urls   map[string]string
+}
+
+// NewUI loads files and renders index.html, where {{asset "css/style.css"}}
+// expands to the hashed URL of that file.
+func NewUI(files fs.FS, prefix string) (*UI, error) {
+	ui := &UI{Prefix: prefix, assets: map[string]uiAsset{}, urls: map[string]string{}}
+	err := fs.WalkDir(files, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || name == uiIndex || path.Ext(name) == ".go" {
+			return err
+		}
+		body, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(path.Base(name), ext) + "." + hex.EncodeToString(sum[:5]) + ext
+		if _, taken := ui.assets[hashed]; taken {
+			return fmt.Errorf("asset %s: name collides with another asset", name)
+		}
+		contentType := mime.TypeByExtension(ext)
+		if contentType == "" {
+			contentType = http.DetectContentType(body)
+		}
+		ui.assets[hashed] = uiAsset{body: body, contentType: contentType}
+		ui.urls[name] = prefix + "/assets/" + hashed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := template.New(uiIndex).Funcs(template.FuncMap{"asset": ui.assetURL}).ParseFS(files, uiIndex)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+	ui.index = buf.Bytes()
+	return ui, nil
+}
+
+func (ui *UI) assetURL(name string) (string, error) {
+	url, ok := ui.urls[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAssetNotFound, name)
+	}
+	return url, nil
+}
+
+func (ui *UI) GetIndex(ctx *gin.Context) {
+	header := ctx.Writer.Header()
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Content-Security-Policy", uiContentSecurityPolicy)
+	header.Set("X-Content-Type-Options", "nosniff")
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", ui.index)
+}
+
+func (ui *UI) GetAsset(ctx *gin.Context) {
+	asset, ok := ui.assets[ctx.Param("name")]
+	if !ok {
+		ctx.Error(fmt.Errorf("%w: %s", ErrAssetNotFound, ctx.Param("name")))
+		return
+	}
+	header := ctx.Writer.Header()
+	header.Set("Cache-Control", immutableCacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
+	ctx.Data(http.StatusOK, asset.contentType, asset.body)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"educabot.com/bookshop/static"
+	"github.com/stretchr/testify/assert"
+)
+
+var uiAssetURL = regexp.MustCompile(`(?:href|src)="(/ui/assets/[^"]+)"`)
+
+func TestUI_ServesEmbeddedDashboard(t *testing.T) {
+	// Arrange
+	ui, err := NewUI(static.Dashboard, "/ui")
+	if !assert.NoError(t, err) {
+		return
+	}
+	router := newTestRouter()
+	router.GET("/ui", ui.GetIndex)
+	router.GET("/ui/assets/:name", ui.GetAsset)
+
+	// Act
+	index := get(router, "/ui", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, index.Code)
+	assert.Equal(t, "text/html; charset=utf-8", index.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", index.Header().Get("Cache-Control"))
+	assert.Contains(t, index.Header().Get("Content-Security-Policy"), "default-src 'self'")
+	assert.NotContains(t, index.Body.String(), "https://")
+
+	urls := uiAssetURL.FindAllStringSubmatch(index.Body.String(), -1)
+	assert.Len(t, urls, 3)
+	expectedTypes := map[string]string{
+		"css": "text/css; charset=utf-8",
+		"js":  "text/javascript; charset=utf-8",
+		"svg": "image/svg+xml",
+	}
+	for _, match := range urls {
+		url := match[1]
+		assert.Regexp(t, `^/ui/assets/[a-z]+\.[0-9a-f]{10}\.(css|js|svg)$`, url)
+
+		asset := get(router, url, nil)
+		assert.Equal(t, http.StatusOK, asset.Code, url)
+		extension := regexp.MustCompile(`\.(\w+)$`).FindStringSubmatch(url)[1]
+		assert.Equal(t, expectedTypes[extension], asset.Header().Get("Content-Type"), url)
+		assert.Equal(t, immutableCacheControl, asset.Header().Get("Cache-Control"), url)
+		assert.Equal(t, "nosniff", asset.Header().Get("X-Content-Type-Options"), url)
+		assert.NotContains(t, asset.Body.String(), "https://", url)
+	}
+}
+
+func TestUI_HashChangesWithContent(t *testing.T) {
+	// Arrange
+	files := fstest.MapFS{
+		"index.html":    {Data: []byte(`<link href="{{asset "css/app.css"}}">`)},
+		"css/app.css":   {Data: []byte("body { color: red; }")},
+		"css/other.css": {Data: []byte("body { color: blue; }")},
+	}
+	changed := fstest.MapFS{
+		"index.html":  files["index.html"],
+		"css/app.css": {Data: []byte("body { color: green; }")},
+	}
+
+	// Act
+	first, err := NewUI(files, "/ui")
+	assert.NoError(t, err)
+	second, err := NewUI(changed, "/ui")
+	assert.NoError(t, err)
+
+	// Assert
+	assert.NotEqual(t, string(first.index), string(second.index))
+	assert.Regexp(t, `^<link href="/ui/assets/app\.[0-9a-f]{10}\.css">$`, string(first.index))
+}
+
+func TestUI_RejectsUnknownAssets(t *testing.T) {
+	// Arrange
+	files := fstest.MapFS{"index.html": {Data: []byte(`{{asset "missing.js"}}`)}}
+
+	// Act
+	_, err := NewUI(files, "/ui")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAssetNotFound)
+}
+
+func TestUI_AssetNotFound(t *testing.T) {
+	// Arrange
+	ui, err := NewUI(static.Dashboard, "/ui")
+	assert.NoError(t, err)
+	router := newTestRouter()
+	router.GET("/ui/assets/:name", ui.GetAsset)
+
+	// Act
+	w := get(router, "/ui/assets/style.0000000000.css", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, MediaTypeProblem, w.Header().Get("Content-Type"))
+}
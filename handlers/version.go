@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MediaTypeV1 = "application/vnd.bookshop.v1+json"
+	MediaTypeV2 = "application/vnd.bookshop.v2+json"
+)
+
+// MetricsV1Response is the frozen /v1 contract. It is deliberately separate
+// from services.MetricsResult so new result fields never leak into v1.
+type MetricsV1Response struct {
+	MeanUnitsSold        uint   `json:"mean_units_sold"`
+	CheapestBook         string `json:"cheapest_book"`
+	BooksWrittenByAuthor uint   `json:"books_written_by_author"`
+}
+
+type MetricsV1Request struct {
+	Author string `form:"author"`
+}
+
+type MetricsV2Response struct {
+	MeanUnitsSold        uint          `json:"mean_units_sold"`
+	CheapestBook         *models.Book  `json:"cheapest_book"`
+	CheapestTies         []models.Book `json:"cheapest_ties"`
+	BooksWrittenByAuthor uint          `json:"books_written_by_author"`
+	TopCheapest          []models.Book `json:"top_cheapest"`
+	TopSellers           []models.Book `json:"top_sellers"`
+}
+
+type MetricsV2Request struct {
+	Author string `form:"author"`
+	TopN   int    `form:"top_n"`
+}
+
+func (h *Handler) GetMetricsV1(ctx *gin.Context) {
+	var query MetricsV1Request
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+
+	result, err := h.service.ComputeMetrics(ctx, query.Author)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MetricsV1Response{
+		MeanUnitsSold:        result.MeanUnitsSold,
+		CheapestBook:         result.CheapestBook,
+		BooksWrittenByAuthor: result.BooksWrittenByAuthor,
+	})
+}
+
+func (h *Handler) GetMetricsV2(ctx *gin.Context) {
+	var query MetricsV2Request
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(&QueryError{Err: err})
+		return
+	}
+
+	result, err := h.service.ComputeMetricsWithOptions(ctx, services.MetricsOptions{
+		Author:   query.Author,
+		Cheapest: services.CheapestTies,
+		TopN:     query.TopN,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	response := MetricsV2Response{
+		MeanUnitsSold:        result.MeanUnitsSold,
+		CheapestTies:         result.CheapestBooks,
+		BooksWrittenByAuthor: result.BooksWrittenByAuthor,
+		TopCheapest:          result.TopCheapest,
+		TopSellers:           result.TopSellers,
+	}
+	if len(result.CheapestBooks) > 0 {
+		response.CheapestBook = &result.CheapestBooks[0]
+	}
+	if response.CheapestTies == nil {
+		response.CheapestTies = []models.Book{}
+	}
+	if response.TopCheapest == nil {
+		response.TopCheapest = []models.Book{}
+	}
+	if response.TopSellers == nil {
+		response.TopSellers = []models.Book{}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetMetricsNegotiated serves the legacy root route. Clients can opt into a
+// versioned contract with an Accept header; otherwise the original response
+// is returned unchanged.
+func (h *Handler) GetMetricsNegotiated(ctx *gin.Context) {
+	ctx.Header("Vary", "Accept")
+	switch AcceptedVersion(ctx.GetHeader("Accept")) {
+	case MediaTypeV2:
+		ctx.Header("Content-Type", MediaTypeV2)
+		h.GetMetricsV2(ctx)
+	case MediaTypeV1:
+		ctx.Header("Content-Type", MediaTypeV1)
+		h.GetMetricsV1(ctx)
+	default:
+		h.GetMetrics(ctx)
+	}
+}
+
+// AcceptedVersion returns the vendor media type requested in an Accept
+// header, or "" when the client did not ask for a specific version.
+func AcceptedVersion(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case MediaTypeV1, MediaTypeV2:
+			return mediaType
+		}
+	}
+	return ""
+}
+
+// Deprecated marks every response of a route as deprecated (RFC 9745) with a
+// planned removal date (RFC 8594) and a link to its replacement.
+func Deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+		ctx.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		if successor != "" {
+			ctx.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/services"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	legacySince  = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	legacySunset = time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// jsonShape maps every top-level key of a JSON object to its JSON type.
+func jsonShape(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+
+	var object map[string]any
+	if err := json.Unmarshal(body, &object); err != nil {
+		t.Fatalf("response is not a JSON object: %v", err)
+	}
+	shape := make(map[string]string, len(object))
+	for key, value := range object {
+		switch value.(type) {
+		case nil:
+			shape[key] = "null"
+		case bool:
+			shape[key] = "boolean"
+		case float64:
+			shape[key] = "number"
+		case string:
+			shape[key] = "string"
+		case []any:
+			shape[key] = "array"
+		default:
+			shape[key] = "object"
+		}
+	}
+	return shape
+}
+
+var metricsV1Schema = map[string]string{
+	"mean_units_sold":         "number",
+	"cheapest_book":           "string",
+	"books_written_by_author": "number",
+}
+
+var metricsV2Schema = map[string]string{
+	"mean_units_sold":         "number",
+	"cheapest_book":           "object",
+	"cheapest_ties":           "array",
+	"books_written_by_author": "number",
+	"top_cheapest":            "array",
+	"top_sellers":             "array",
+}
+
+func TestContract_MetricsV1(t *testing.T) {
+	// Arrange
+	handler := NewHandler(services.NewMetricsService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/v1/metrics", handler.GetMetricsV1)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/v1/metrics?author=Alan%20Donovan&top_n=2&cheapest=ties", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metricsV1Schema, jsonShape(t, w.Body.Bytes()))
+	assert.JSONEq(t, `{
+		"mean_units_sold": 11000,
+		"cheapest_book": "The Go Programming Language",
+		"books_written_by_author": 1
+	}`, w.Body.String())
+}
+
+func TestContract_MetricsV2(t *testing.T) {
+	// Arrange
+	handler := NewHandler(services.NewMetricsService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/v2/metrics", handler.GetMetricsV2)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/v2/metrics?top_n=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metricsV2Schema, jsonShape(t, w.Body.Bytes()))
+
+	var response MetricsV2Response
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "The Go Programming Language", response.CheapestBook.Name)
+	assert.Len(t, response.CheapestTies, 1)
+	assert.Equal(t, "The Go Programming Language", response.TopCheapest[0].Name)
+	assert.Equal(t, "Clean Code", response.TopSellers[0].Name)
+}
+
+func TestContract_MetricsV2_ListsAreNeverNull(t *testing.T) {
+	// Arrange
+	handler := NewHandler(services.NewMetricsService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/v2/metrics", handler.GetMetricsV2)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/v2/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metricsV2Schema, jsonShape(t, w.Body.Bytes()))
+}
+
+func TestContract_LegacyRootIsDeprecated(t *testing.T) {
+	// Arrange
+	handler := NewHandler(services.NewMetricsService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/", Deprecated(legacySince, legacySunset, "/v1/metrics"), handler.GetMetricsNegotiated)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metricsV1Schema, jsonShape(t, w.Body.Bytes()))
+	assert.Equal(t, "@1735689600", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/metrics>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+}
+
+func TestContract_AcceptHeaderSelectsVersion(t *testing.T) {
+	// Arrange
+	handler := NewHandler(services.NewMetricsService(mockImpls.NewMockBooksRepositories()))
+	router := newTestRouter()
+	router.GET("/", Deprecated(legacySince, legacySunset, "/v1/metrics"), handler.GetMetricsNegotiated)
+
+	tests := []struct {
+		accept      string
+		contentType string
+		schema      map[string]string
+	}{
+		{MediaTypeV2, MediaTypeV2, metricsV2Schema},
+		{"text/html, " + MediaTypeV1 + ";q=0.9", MediaTypeV1, metricsV1Schema},
+		{"application/json", "application/json; charset=utf-8", metricsV1Schema},
+	}
+
+	for _, tt := range tests {
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code, tt.accept)
+		assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"), tt.accept)
+		assert.Equal(t, tt.schema, jsonShape(t, w.Body.Bytes()), tt.accept)
+	}
+}
+
+func TestAcceptedVersion(t *testing.T) {
+	assert.Equal(t, MediaTypeV2, AcceptedVersion("application/vnd.bookshop.v2+json"))
+	assert.Equal(t, MediaTypeV1, AcceptedVersion("application/json, application/vnd.bookshop.v1+json; q=0.5"))
+	assert.Equal(t, "", AcceptedVersion("*/*"))
+	assert.Equal(t, "", AcceptedVersion(""))
+}
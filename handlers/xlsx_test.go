@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/services"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func readXLSXPart(t *testing.T, workbook []byte, name string) string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	part, err := archive.Open(name)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer part.Close()
+	content, err := io.ReadAll(part)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestXLSXWriter_Workbook(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	writer, err := newXLSXWriter(&buf, "books & more")
+	assert.NoError(t, err)
+
+	// Act
+	assert.NoError(t, writer.WriteHeader([]string{"Title", "Price"}))
+	assert.NoError(t, writer.WriteRow([]any{"<Clean> Code ", uint(50)}))
+	assert.NoError(t, writer.WriteRow([]any{"=SUM(A1)", 12.5}))
+	assert.NoError(t, writer.Close())
+
+	// Assert
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.NoError(t, xml.Unmarshal([]byte(readXLSXPart(t, buf.Bytes(), name)), new(struct{})), name)
+	}
+	assert.Contains(t, readXLSXPart(t, buf.Bytes(), "xl/workbook.xml"), `<sheet name="books &amp; more" sheetId="1" r:id="rId1"/>`)
+	sheet := readXLSXPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.NoError(t, xml.Unmarshal([]byte(sheet), new(struct{})))
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Title</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;Clean&gt; Code </t></is></c><c r="B2"><v>50</v></c>`)
+	assert.Contains(t, sheet, `<c r="A3" t="inlineStr"><is><t xml:space="preserve">=SUM(A1)</t></is></c><c r="B3"><v>12.5</v></c>`)
+	assert.True(t, strings.HasSuffix(sheet, `</sheetData></worksheet>`))
+}
+
+func TestExportHandler_XLSX(t *testing.T) {
+	// Arrange
+	handler := NewExportHandler(services.NewExportService(&testutil.StaticBooksRepository{Books: []models.Book{{ID: 1, Name: "Dune", Author: "Frank Herbert", UnitsSold: 7, Price: 20}}}))
+	router := newTestRouter()
+	router.GET("/export/books", handler.GetBooks)
+
+	// Act
+	w := get(router, "/export/books?format=xlsx", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MediaTypeXLSX, w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=books.xlsx", w.Header().Get("Content-Disposition"))
+	sheet := readXLSXPart(t, w.Body.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheet, `<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">Dune</t></is></c>`)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+	assert.Equal(t, "ZZ", columnName(701))
+	assert.Equal(t, "AAA", columnName(702))
+}
@@ -1,37 +1,84 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"educabot.com/bookshop/handlers"
+	"educabot.com/bookshop/config"
 	"educabot.com/bookshop/repositories"
-	"educabot.com/bookshop/services"
+	"educabot.com/bookshop/server"
 	"github.com/gin-gonic/gin"
 )
 
-func setupRouter() *gin.Engine {
-	router := gin.Default()
-	router.SetTrustedProxies(nil)
+// shutdownTimeout bounds how long requests in flight may take to finish
+// once the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
 
-	// Books repository
-	booksRepo := repositories.NewExternalBooksRepository("https://6781684b85151f714b0aa5db.mockapi.io/api/v1/books")
-
-	// Servicio con lógica
-	service := services.NewMetricsService(booksRepo)
-
-	// Handler con dependencias
-	handler := handlers.NewHandler(service)
+// dependencies are what setupRouter reads from outside the process. Tests
+// replace them to run without the network.
+type dependencies struct {
+	getenv   func(string) string
+	booksURL string
+}
 
-	// Rutas
-	router.GET("/", handler.GetMetrics)
+func defaultDependencies() dependencies {
+	return dependencies{getenv: os.Getenv, booksURL: repositories.DefaultBooksURL}
+}
 
-	return router
+// setupRouter builds the API. The background catalog polling it starts stops
+// when ctx is done, and wait returns once it has. An invalid BOOKSHOP_*
+// variable is an error: falling back to defaults could drop upstream
+// credentials or replay settings without anyone noticing.
+func setupRouter(ctx context.Context, deps dependencies) (router *gin.Engine, wait func(), err error) {
+	cfg, err := config.Load(deps.getenv)
+	if err != nil {
+		return nil, nil, err
+	}
+	booksRepo, wait, err := server.NewBooksRepository(ctx, cfg.Books, deps.booksURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return server.NewRouter(cfg, booksRepo), wait, nil
 }
 
 func main() {
-	router := setupRouter()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Workers outlive ctx so requests still draining see a live catalog.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	router, wait, err := setupRouter(workerCtx, defaultDependencies())
+	if err != nil {
+		log.Fatalf("startup: %v", err)
+	}
+	srv := &http.Server{Addr: ":3000", Handler: router}
+
+	// ListenAndServe returns as soon as Shutdown starts; wait for requests
+	// in flight before exiting.
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("shutdown", slog.String("error", err.Error()))
+		}
+	}()
 
 	// Iniciar servidor
 	fmt.Println("🚀 Starting server on :3000")
-	router.Run(":3000")
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	<-drained
+	stopWorkers()
+	wait()
 }
@@ -1,30 +1,99 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"educabot.com/bookshop/auth"
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/handlers"
 	"educabot.com/bookshop/services"
+	"educabot.com/bookshop/testutil"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
+// testDependencies points setupRouter at a fake upstream serving
+// testutil.Books, so no test reaches the real mockapi, and reads the
+// environment from env instead of the process.
+func testDependencies(t *testing.T, env map[string]string) dependencies {
+	upstream := testutil.NewUpstream(t, testutil.Books())
+	return dependencies{getenv: mapEnv(env), booksURL: upstream.BooksURL()}
+}
+
+func mapEnv(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+// newTestRouter fails t when setupRouter does and stops the workers it
+// starts when t ends.
+func newTestRouter(t *testing.T, deps dependencies) *gin.Engine {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	router, wait, err := setupRouter(ctx, deps)
+	if err != nil {
+		cancel()
+		t.Fatalf("setupRouter: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		wait()
+	})
+	return router
+}
+
 func TestSetupRouter(t *testing.T) {
 	// Arrange & Act
 	gin.SetMode(gin.TestMode)
-	router := setupRouter()
+	router := newTestRouter(t, testDependencies(t, nil))
 
 	// Assert
 	assert.NotNil(t, router)
 }
 
+func TestSetupRouter_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"unreadable secret file", map[string]string{"BOOKSHOP_UPSTREAM_AUTH": "bearer", "BOOKSHOP_UPSTREAM_TOKEN_FILE": "/nonexistent/token"}, "BOOKSHOP_UPSTREAM_TOKEN_FILE"},
+		{"unknown cassette mode", map[string]string{"BOOKSHOP_CASSETTE_FILE": "incident.json", "BOOKSHOP_CASSETTE_MODE": "rewind"}, "BOOKSHOP_CASSETTE_MODE"},
+		{"invalid recommender weights", map[string]string{"BOOKSHOP_RECOMMENDER_WEIGHTS": "author=-1"}, "BOOKSHOP_RECOMMENDER_WEIGHTS"},
+		{"missing co-purchases file", map[string]string{"BOOKSHOP_COPURCHASES_FILE": "/nonexistent/copurchases.json"}, "BOOKSHOP_COPURCHASES_FILE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			upstream := testutil.NewUpstream(t, testutil.Books())
+
+			// Act
+			router, _, err := setupRouter(context.Background(), dependencies{getenv: mapEnv(tt.env), booksURL: upstream.BooksURL()})
+
+			// Assert
+			assert.ErrorIs(t, err, config.ErrInvalidConfig)
+			assert.ErrorContains(t, err, tt.want)
+			assert.Nil(t, router)
+			assert.Zero(t, upstream.Requests())
+		})
+	}
+}
+
 func TestMain_GetMetrics_Integration(t *testing.T) {
 	// Arrange
 	gin.SetMode(gin.TestMode)
-	router := setupRouter()
+	router := newTestRouter(t, testDependencies(t, nil))
 
 	// Act
 	author := url.QueryEscape("Robert C. Martin")
@@ -39,15 +108,15 @@ func TestMain_GetMetrics_Integration(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &result)
 	assert.NoError(t, err)
 
-	// This will test against the real external API
-	assert.NotNil(t, result.MeanUnitsSold)
-	assert.NotEmpty(t, result.CheapestBook)
+	assert.Equal(t, uint(11000), result.MeanUnitsSold)
+	assert.Equal(t, "The Go Programming Language", result.CheapestBook)
+	assert.Equal(t, uint(1), result.BooksWrittenByAuthor)
 }
 
 func TestMain_GetMetrics_Integration_NoAuthor(t *testing.T) {
 	// Arrange
 	gin.SetMode(gin.TestMode)
-	router := setupRouter()
+	router := newTestRouter(t, testDependencies(t, nil))
 
 	// Act
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -61,15 +130,48 @@ func TestMain_GetMetrics_Integration_NoAuthor(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &result)
 	assert.NoError(t, err)
 
-	assert.NotNil(t, result.MeanUnitsSold)
-	assert.NotEmpty(t, result.CheapestBook)
+	assert.Equal(t, uint(11000), result.MeanUnitsSold)
+	assert.Equal(t, "The Go Programming Language", result.CheapestBook)
 	assert.Equal(t, uint(0), result.BooksWrittenByAuthor)
 }
 
+func TestMain_UpstreamFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		fault  testutil.Fault
+		status int
+		code   string
+	}{
+		{"server error", testutil.Fault{Status: http.StatusInternalServerError}, http.StatusBadGateway, "upstream_bad_status"},
+		{"truncated body", testutil.Fault{Truncate: true}, http.StatusBadGateway, "upstream_decode_failed"},
+		{"malformed body", testutil.Fault{Malformed: true}, http.StatusBadGateway, "upstream_decode_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			upstream := testutil.NewUpstream(t, testutil.Books())
+			upstream.SetFault(tt.fault)
+			router := newTestRouter(t, dependencies{getenv: mapEnv(nil), booksURL: upstream.BooksURL()})
+
+			// Act
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			var problem handlers.Problem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, tt.code, problem.Code)
+		})
+	}
+}
+
 func TestMain_RouteNotFound(t *testing.T) {
 	// Arrange
 	gin.SetMode(gin.TestMode)
-	router := setupRouter()
+	router := newTestRouter(t, testDependencies(t, nil))
 
 	// Act
 	req := httptest.NewRequest(http.MethodGet, "/nonexistent", nil)
@@ -79,3 +181,376 @@ func TestMain_RouteNotFound(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestMain_EveryRouteIsDocumented(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := newTestRouter(t, testDependencies(t, nil))
+
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	err := json.Unmarshal(handlers.OpenAPISpec, &spec)
+	assert.NoError(t, err)
+
+	// Act & Assert
+	ginParam := regexp.MustCompile(`:([A-Za-z_]+)`)
+	for _, route := range router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		item, ok := spec.Paths[path]
+		if !assert.True(t, ok, "%s %s is not in openapi.json", route.Method, path) {
+			continue
+		}
+		if _, aliased := item["$ref"]; !aliased && route.Method != http.MethodHead {
+			assert.Contains(t, item, strings.ToLower(route.Method), "%s %s is not in openapi.json", route.Method, path)
+		}
+	}
+}
+
+func TestMain_ServesDocs(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := newTestRouter(t, testDependencies(t, nil))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `fetch("/openapi.json")`)
+}
+
+func TestMain_ServesDocsOutsideRepo(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	router := newTestRouter(t, testDependencies(t, nil))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `fetch("/openapi.json")`)
+}
+
+func TestMain_CompressesResponses(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := newTestRouter(t, testDependencies(t, nil))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.True(t, strings.HasPrefix(w.Header().Get("ETag"), `W/"`))
+}
+
+func TestMain_RateLimitFromEnvironment(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	env := map[string]string{
+		"BOOKSHOP_RATE_LIMIT_RATE":  "0.01",
+		"BOOKSHOP_RATE_LIMIT_BURST": "1",
+	}
+	router := newTestRouter(t, testDependencies(t, env))
+
+	// Act
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, "100", second.Header().Get("Retry-After"))
+}
+
+func TestMain_DistributionRequiresCredentials(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := newTestRouter(t, testDependencies(t, nil))
+
+	for _, path := range []string{"/metrics/distribution", "/v1/metrics/distribution", "/v2/metrics/distribution"} {
+		// Act
+		req := httptest.NewRequest(http.MethodGet, path+"?field=price", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		assert.Equal(t, handlers.MediaTypeProblem, w.Header().Get("Content-Type"), path)
+	}
+}
+
+func TestMain_AnalyticsRequireMetricsRead(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	keys := `[
+		{"name": "finance", "sha256": "` + auth.HashAPIKey("finance-secret") + `", "scopes": ["metrics:read"]},
+		{"name": "catalog", "sha256": "` + auth.HashAPIKey("catalog-secret") + `", "scopes": []}
+	]`
+	assert.NoError(t, os.WriteFile(keysFile, []byte(keys), 0o600))
+	env := map[string]string{
+		"BOOKSHOP_API_KEYS_FILE":    keysFile,
+		"BOOKSHOP_RATE_LIMIT_BURST": "100",
+	}
+	router := newTestRouter(t, testDependencies(t, env))
+
+	tests := []struct {
+		name   string
+		apiKey string
+		want   int
+	}{
+		{"no credentials", "", http.StatusUnauthorized},
+		{"key without scope", "catalog-secret", http.StatusForbidden},
+		{"key with scope", "finance-secret", http.StatusOK},
+	}
+
+	for _, path := range []string{"/reports/summary", "/v1/reports/summary", "/v2/reports/summary", "/export/books", "/v1/export/books", "/export/authors", "/v2/export/authors"} {
+		for _, tt := range tests {
+			t.Run(path+"/"+tt.name, func(t *testing.T) {
+				// Act
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.apiKey != "" {
+					req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				// Assert
+				assert.Equal(t, tt.want, w.Code)
+			})
+		}
+	}
+}
+
+func TestMain_CORSFromEnvironment(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	env := map[string]string{
+		"BOOKSHOP_CORS_ALLOWED_ORIGINS":   "https://app.example.com,https://*.example.org",
+		"BOOKSHOP_CORS_ALLOW_CREDENTIALS": "true",
+	}
+	router := newTestRouter(t, testDependencies(t, env))
+
+	// Act
+	preflight := httptest.NewRequest(http.MethodOptions, "/v2/metrics/distribution", nil)
+	preflight.Header.Set("Origin", "https://reports.example.org")
+	preflight.Header.Set("Access-Control-Request-Method", "GET")
+	preflight.Header.Set("Access-Control-Request-Headers", "authorization")
+	preflightResponse := httptest.NewRecorder()
+	router.ServeHTTP(preflightResponse, preflight)
+
+	simple := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	simple.Header.Set("Origin", "https://app.example.com")
+	simpleResponse := httptest.NewRecorder()
+	router.ServeHTTP(simpleResponse, simple)
+
+	rejected := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rejected.Header.Set("Origin", "https://evil.example.net")
+	rejectedResponse := httptest.NewRecorder()
+	router.ServeHTTP(rejectedResponse, rejected)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, preflightResponse.Code)
+	assert.Equal(t, "https://reports.example.org", preflightResponse.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", preflightResponse.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, HEAD", preflightResponse.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(t, preflightResponse.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Equal(t, "600", preflightResponse.Header().Get("Access-Control-Max-Age"))
+
+	assert.Equal(t, http.StatusOK, simpleResponse.Code)
+	assert.Equal(t, "https://app.example.com", simpleResponse.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, simpleResponse.Header().Get("Access-Control-Expose-Headers"), "ETag")
+	assert.Contains(t, simpleResponse.Header().Values("Vary"), "Origin")
+
+	assert.Equal(t, http.StatusForbidden, rejectedResponse.Code)
+	assert.Equal(t, handlers.MediaTypeProblem, rejectedResponse.Header().Get("Content-Type"))
+	assert.Empty(t, rejectedResponse.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestMain_CrossOriginRejectedByDefault(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := newTestRouter(t, testDependencies(t, nil))
+	req := httptest.NewRequest(http.MethodOptions, "/search", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+
+	// Act
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestMain_ServesDashboard(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := newTestRouter(t, testDependencies(t, nil))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/ui", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Bookshop dashboard")
+	assert.Regexp(t, `src="/ui/assets/dashboard\.[0-9a-f]{10}\.js"`, w.Body.String())
+}
+
+func TestMain_ServesBooksFile(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	env := map[string]string{
+		"BOOKSHOP_BOOKS_FILE": "repositories/testdata/books.csv",
+	}
+	router := newTestRouter(t, testDependencies(t, env))
+
+	// Act
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics?author=Andrew+Hunt", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+	assert.Equal(t, "public, max-age=0", w.Header().Get("Cache-Control"))
+	var response handlers.MetricsV1Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, uint(11000), response.MeanUnitsSold)
+	assert.Equal(t, uint(1), response.BooksWrittenByAuthor)
+}
+
+func TestMain_ReplaysCassette(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	env := map[string]string{
+		"BOOKSHOP_CASSETTE_FILE": "repositories/testdata/cassettes/mockapi.json",
+	}
+	router := newTestRouter(t, testDependencies(t, env))
+
+	// Act
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response handlers.MetricsV1Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, uint(11000), response.MeanUnitsSold)
+}
+
+func TestMain_UpstreamOAuth2(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	tokens := testutil.NewTokenServer(t, "reports", "s3cr3t")
+	upstream := testutil.NewUpstream(t, testutil.Books())
+	upstream.SetAuthorize(tokens.Authorize)
+	secretFile := filepath.Join(t.TempDir(), "client-secret")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("s3cr3t\n"), 0o600))
+	env := map[string]string{
+		"BOOKSHOP_UPSTREAM_AUTH":               "oauth2",
+		"BOOKSHOP_UPSTREAM_TOKEN_URL":          tokens.TokenURL(),
+		"BOOKSHOP_UPSTREAM_CLIENT_ID":          "reports",
+		"BOOKSHOP_UPSTREAM_CLIENT_SECRET_FILE": secretFile,
+		"BOOKSHOP_UPSTREAM_SCOPES":             "catalog.read",
+	}
+	router := newTestRouter(t, dependencies{getenv: mapEnv(env), booksURL: upstream.BooksURL()})
+
+	// Act
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, tokens.Issued())
+	assert.Equal(t, []string{"catalog.read"}, tokens.Scopes())
+}
+
+func TestMain_UpstreamAuthFailure(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	tokens := testutil.NewTokenServer(t, "reports", "s3cr3t")
+	env := map[string]string{
+		"BOOKSHOP_UPSTREAM_AUTH":          "oauth2",
+		"BOOKSHOP_UPSTREAM_TOKEN_URL":     tokens.TokenURL(),
+		"BOOKSHOP_UPSTREAM_CLIENT_ID":     "reports",
+		"BOOKSHOP_UPSTREAM_CLIENT_SECRET": "wrong",
+	}
+	router := newTestRouter(t, testDependencies(t, env))
+
+	// Act
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	var problem handlers.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "upstream_auth_failed", problem.Code)
+	assert.NotContains(t, w.Body.String(), "wrong")
+}
+
+func TestMain_ServesCatalogSnapshot(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	upstream := testutil.NewUpstream(t, testutil.Books())
+	router := newTestRouter(t, dependencies{getenv: mapEnv(nil), booksURL: upstream.BooksURL()})
+	upstream.SetFault(testutil.Fault{Status: http.StatusInternalServerError})
+
+	// Act
+	codes := make([]int, 3)
+	for i := range codes {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+		codes[i] = w.Code
+	}
+
+	// Assert
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK}, codes)
+	assert.Equal(t, 1, upstream.Requests())
+}
+
+func TestMain_RefreshesCatalogInBackground(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	env := map[string]string{
+		"BOOKSHOP_BOOKS_REFRESH_INTERVAL": "10ms",
+	}
+	upstream := testutil.NewUpstream(t, testutil.Books())
+	router := newTestRouter(t, dependencies{getenv: mapEnv(env), booksURL: upstream.BooksURL()})
+	meanUnitsSold := func() uint {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+		var response handlers.MetricsV1Response
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.MeanUnitsSold
+	}
+	before := meanUnitsSold()
+
+	// Act
+	upstream.SetBooks(testutil.Books()[:1])
+
+	// Assert
+	assert.Equal(t, uint(11000), before)
+	assert.Eventually(t, func() bool { return meanUnitsSold() == 5000 }, time.Second, 10*time.Millisecond)
+}
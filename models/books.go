@@ -6,4 +6,5 @@ type Book struct {
 	Author    string `json:"author"`
 	UnitsSold uint   `json:"units_sold"`
 	Price     uint   `json:"price"`
+	ISBN      string `json:"isbn,omitempty"`
 }
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrISBNEmpty      = errors.New("isbn is empty")
+	ErrISBNLength     = errors.New("isbn must have 10 or 13 digits")
+	ErrISBNCharacters = errors.New("isbn contains invalid characters")
+	ErrISBNChecksum   = errors.New("isbn checksum mismatch")
+	ErrISBNPrefix     = errors.New("isbn-13 must start with 978 or 979")
+)
+
+// NormalizeISBN validates an ISBN-10 or ISBN-13 and returns it as a bare
+// ISBN-13. Hyphens and spaces are ignored.
+func NormalizeISBN(raw string) (string, error) {
+	isbn := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	switch len(isbn) {
+	case 0:
+		return "", ErrISBNEmpty
+	case 10:
+		if err := validateISBN10(isbn); err != nil {
+			return "", err
+		}
+		return isbn10To13(isbn), nil
+	case 13:
+		if err := validateISBN13(isbn); err != nil {
+			return "", err
+		}
+		return isbn, nil
+	default:
+		return "", ErrISBNLength
+	}
+}
+
+func validateISBN10(isbn string) error {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var digit int
+		switch c := isbn[i]; {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case (c == 'X' || c == 'x') && i == 9:
+			digit = 10
+		default:
+			return ErrISBNCharacters
+		}
+		sum += (10 - i) * digit
+	}
+	if sum%11 != 0 {
+		return ErrISBNChecksum
+	}
+	return nil
+}
+
+func validateISBN13(isbn string) error {
+	for i := 0; i < 13; i++ {
+		if isbn[i] < '0' || isbn[i] > '9' {
+			return ErrISBNCharacters
+		}
+	}
+	if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+		return ErrISBNPrefix
+	}
+	if isbn13CheckDigit(isbn[:12]) != isbn[12] {
+		return ErrISBNChecksum
+	}
+	return nil
+}
+
+func isbn10To13(isbn string) string {
+	body := "978" + isbn[:9]
+	return body + string(isbn13CheckDigit(body))
+}
+
+func isbn13CheckDigit(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(body[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeISBN_ValidISBN13(t *testing.T) {
+	// Act
+	isbn, err := NormalizeISBN("978-0-13-235088-4")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "9780132350884", isbn)
+}
+
+func TestNormalizeISBN_ConvertsISBN10(t *testing.T) {
+	// Act
+	isbn, err := NormalizeISBN("0-201-61622-X")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "9780201616224", isbn)
+}
+
+func TestNormalizeISBN_LowercaseCheckCharacter(t *testing.T) {
+	// Act
+	isbn, err := NormalizeISBN("020161622x")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "9780201616224", isbn)
+}
+
+func TestNormalizeISBN_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"empty", " ", ErrISBNEmpty},
+		{"wrong length", "12345", ErrISBNLength},
+		{"letters in isbn-13", "97801323508A4", ErrISBNCharacters},
+		{"x before last position", "0X01616224", ErrISBNCharacters},
+		{"isbn-10 checksum", "0201616221", ErrISBNChecksum},
+		{"isbn-13 checksum", "9780132350885", ErrISBNChecksum},
+		{"isbn-13 prefix", "1230132350884", ErrISBNPrefix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			isbn, err := NormalizeISBN(tt.input)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.err)
+			assert.Empty(t, isbn)
+		})
+	}
+}
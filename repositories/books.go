@@ -4,47 +4,125 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"educabot.com/bookshop/models"
 )
 
-var ErrServiceUnavailable = errors.New("external service failure")
+// DefaultBooksURL is the upstream catalog the API serves unless told otherwise.
+const DefaultBooksURL = "https://6781684b85151f714b0aa5db.mockapi.io/api/v1/books"
 
 type BooksRepository interface {
 	GetBooksProvider(ctx context.Context) ([]models.Book, error)
 }
 
+// maxDrainedBody is how much of an unread body is discarded to keep its
+// connection; longer bodies close it instead.
+const maxDrainedBody = 64 << 10
+
+// DefaultUpstreamTimeout bounds a whole upstream request, body included.
+const DefaultUpstreamTimeout = 10 * time.Second
+
+// DefaultUserAgent identifies the API to the upstream.
+const DefaultUserAgent = "bookshop/1.0"
+
+// ExternalBooksOptions configure how ExternalBooksRepository reaches the
+// upstream. The zero value uses a shared, pooled transport.
+type ExternalBooksOptions struct {
+	// Client is used as is; Transport and Timeout are then ignored.
+	Client *http.Client
+	// Transport sends the requests, for example a CassetteTransport or one
+	// built by NewTransport. Nil means the shared default transport.
+	Transport http.RoundTripper
+	// Timeout defaults to DefaultUpstreamTimeout.
+	Timeout   time.Duration
+	UserAgent string
+	// Credentials authorize every request; nil sends none.
+	Credentials Credentials
+}
+
 type ExternalBooksRepository struct {
-	Endpoint string
+	Endpoint    string
+	client      *http.Client
+	userAgent   string
+	credentials Credentials
 }
 
 func NewExternalBooksRepository(endpoint string) *ExternalBooksRepository {
-	return &ExternalBooksRepository{Endpoint: endpoint}
+	return NewExternalBooksRepositoryWithOptions(endpoint, ExternalBooksOptions{})
+}
+
+// NewExternalBooksRepositoryWithOptions builds the client once, so every
+// call reuses its pooled connections.
+func NewExternalBooksRepositoryWithOptions(endpoint string, opts ExternalBooksOptions) *ExternalBooksRepository {
+	client := opts.Client
+	if client == nil {
+		transport := opts.Transport
+		if transport == nil {
+			transport = defaultTransport
+		}
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = DefaultUpstreamTimeout
+		}
+		client = &http.Client{Timeout: timeout, Transport: transport}
+	}
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &ExternalBooksRepository{Endpoint: endpoint, client: client, userAgent: userAgent, credentials: opts.Credentials}
 }
 
 func (r *ExternalBooksRepository) GetBooksProvider(ctx context.Context) ([]models.Book, error) {
+	books, err := r.getBooks(ctx)
+	var statusErr *UpstreamStatusError
+	if cached, ok := r.credentials.(invalidator); ok && errors.As(err, &statusErr) && statusErr.Code == http.StatusUnauthorized {
+		// A cached token may have been revoked before it expired.
+		cached.Invalidate()
+		books, err = r.getBooks(ctx)
+	}
+	return books, err
+}
+
+func (r *ExternalBooksRepository) getBooks(ctx context.Context) ([]models.Book, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	req.Header.Set("User-Agent", r.userAgent)
+	if r.credentials != nil {
+		if err := r.credentials.Apply(ctx, req); err != nil {
+			return nil, err
+		}
+	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, ErrServiceUnavailable
+		return nil, transportError(err)
 	}
-	defer resp.Body.Close()
+	// Draining what the decoder left lets the connection go back to the pool.
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+		resp.Body.Close()
+	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("external service returned status %d", resp.StatusCode)
+		return nil, &UpstreamStatusError{Code: resp.StatusCode}
 	}
 
+	body, err := decodedBody(resp)
+	if err != nil {
+		return nil, &UpstreamDecodeError{Err: err}
+	}
+	defer body.Close()
+
 	var books []models.Book
-	if err := json.NewDecoder(resp.Body).Decode(&books); err != nil {
-		return nil, err
+	if err := json.NewDecoder(body).Decode(&books); err != nil {
+		return nil, &UpstreamDecodeError{Err: err}
 	}
 
 	return books, nil
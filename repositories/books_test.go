@@ -3,8 +3,11 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -57,7 +60,9 @@ func TestExternalBooksRepository_GetBooks_NetworkError(t *testing.T) {
 	books, err := repo.GetBooksProvider(ctx)
 
 	// Assert
-	assert.Equal(t, ErrServiceUnavailable, err)
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	var timeoutErr *UpstreamTimeoutError
+	assert.False(t, errors.As(err, &timeoutErr))
 	assert.Nil(t, books)
 }
 
@@ -77,6 +82,10 @@ func TestExternalBooksRepository_GetBooks_NonOKStatus(t *testing.T) {
 	// Assert
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "external service returned status 500")
+	assert.ErrorIs(t, err, ErrUpstreamStatus)
+	var statusErr *UpstreamStatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusInternalServerError, statusErr.Code)
 	assert.Nil(t, books)
 }
 
@@ -96,6 +105,11 @@ func TestExternalBooksRepository_GetBooks_InvalidJSON(t *testing.T) {
 
 	// Assert
 	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrUpstreamDecode)
+	var decodeErr *UpstreamDecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	var syntaxErr *json.SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr))
 	assert.Nil(t, books)
 }
 
@@ -115,7 +129,10 @@ func TestExternalBooksRepository_GetBooks_ContextCancellation(t *testing.T) {
 	books, err := repo.GetBooksProvider(ctx)
 
 	// Assert
-	assert.Equal(t, ErrServiceUnavailable, err)
+	var timeoutErr *UpstreamTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
 	assert.Nil(t, books)
 }
 
@@ -149,3 +166,121 @@ func TestNewExternalBooksRepository(t *testing.T) {
 	assert.NotNil(t, repo)
 	assert.Equal(t, endpoint, repo.Endpoint)
 }
+
+func TestExternalBooksRepository_Options(t *testing.T) {
+	// Arrange
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		json.NewEncoder(w).Encode([]models.Book{})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		opts      ExternalBooksOptions
+		userAgent string
+	}{
+		{"defaults", ExternalBooksOptions{}, DefaultUserAgent},
+		{"user agent", ExternalBooksOptions{UserAgent: "reports-job/2"}, "reports-job/2"},
+		{"shared client", ExternalBooksOptions{Client: server.Client()}, DefaultUserAgent},
+		{"transport", ExternalBooksOptions{Transport: server.Client().Transport, Timeout: time.Second}, DefaultUserAgent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewExternalBooksRepositoryWithOptions(server.URL, tt.opts)
+
+			// Act
+			_, err := repo.GetBooksProvider(context.Background())
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.userAgent, userAgent)
+		})
+	}
+}
+
+func TestExternalBooksRepository_Timeout(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	repo := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Timeout: 50 * time.Millisecond})
+
+	// Act
+	_, err := repo.GetBooksProvider(context.Background())
+
+	// Assert
+	var timeoutErr *UpstreamTimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+}
+
+// countingServer counts the TCP connections its clients open.
+func countingServer(tb testing.TB) (*httptest.Server, *atomic.Int64) {
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]models.Book{{ID: 1, Name: "Test Book", Author: "Test Author"}})
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	tb.Cleanup(server.Close)
+	return server, &conns
+}
+
+func TestExternalBooksRepository_ReusesConnections(t *testing.T) {
+	// Arrange
+	server, conns := countingServer(t)
+	transport, err := NewTransport(TransportOptions{})
+	assert.NoError(t, err)
+	repo := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Transport: transport})
+
+	// Act
+	for range 20 {
+		_, err := repo.GetBooksProvider(context.Background())
+		assert.NoError(t, err)
+	}
+
+	// Assert
+	assert.Equal(t, int64(1), conns.Load())
+}
+
+// BenchmarkExternalBooksRepository compares one repository, whose client is
+// built once, with the old behaviour of a fresh client per call. The
+// conns/op metric shows whether connections are reused.
+func BenchmarkExternalBooksRepository(b *testing.B) {
+	b.Run("shared client", func(b *testing.B) {
+		server, conns := countingServer(b)
+		transport, _ := NewTransport(TransportOptions{})
+		repo := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Transport: transport})
+		b.ResetTimer()
+		for range b.N {
+			if _, err := repo.GetBooksProvider(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	})
+
+	b.Run("client per call", func(b *testing.B) {
+		server, conns := countingServer(b)
+		b.ResetTimer()
+		for range b.N {
+			transport, _ := NewTransport(TransportOptions{})
+			repo := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Transport: transport})
+			if _, err := repo.GetBooksProvider(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+			transport.CloseIdleConnections()
+		}
+		b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	})
+}
@@ -0,0 +1,384 @@
+package repositories
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type CassetteMode string
+
+const (
+	// CassetteRecord forwards requests upstream and saves every exchange,
+	// except one that repeats the last response to the same request.
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers from the cassette and never touches the network.
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ErrCassetteMiss reports a replayed request with no recorded match. It is
+// also an ErrServiceUnavailable, as no upstream answered.
+var ErrCassetteMiss = errors.New("no recorded interaction matches the request")
+
+// DefaultCassetteMatch ignores the host, so a cassette recorded against
+// production replays behind any URL.
+var DefaultCassetteMatch = []string{"method", "path", "query"}
+
+// redactedHeaders never reach a cassette with their values.
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie", "X-Api-Key"}
+
+// redactedQueryWords mark query parameters whose values are secrets.
+var redactedQueryWords = []string{"key", "password", "secret", "signature", "token"}
+
+const redacted = "REDACTED"
+
+// Cassette is the file format: the exchanges in the order they happened.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	RecordedAt time.Time        `json:"recorded_at"`
+	Request    RecordedRequest  `json:"request"`
+	Response   RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+}
+
+// RecordedResponse holds the body with its Content-Encoding removed, as
+// text when it is UTF-8 and as BodyBase64 otherwise.
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"body_base64,omitempty"`
+}
+
+// CassetteMissError is ErrCassetteMiss for one request.
+type CassetteMissError struct {
+	Method string
+	URL    string
+}
+
+func (e *CassetteMissError) Error() string {
+	return fmt.Sprintf("%v: %s %s", ErrCassetteMiss, e.Method, e.URL)
+}
+
+func (e *CassetteMissError) Is(target error) bool {
+	return target == ErrCassetteMiss || target == ErrServiceUnavailable
+}
+
+type CassetteOptions struct {
+	// Match lists what a recorded request must share with a live one:
+	// "method", "host", "path", "query" or "header:<Name>". Empty means
+	// DefaultCassetteMatch.
+	Match []string
+	// RedactHeaders are redacted on top of Authorization, cookies and
+	// X-Api-Key.
+	RedactHeaders []string
+	// Transport sends recorded requests; nil means the transport
+	// ExternalBooksRepository uses by default.
+	Transport http.RoundTripper
+}
+
+// CassetteTransport is an http.RoundTripper that records upstream exchanges
+// to a cassette file or replays them from one. Secrets in headers and query
+// strings are redacted before anything is written.
+type CassetteTransport struct {
+	path     string
+	mode     CassetteMode
+	match    []string
+	redact   []string
+	next     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+	played   []bool
+}
+
+// NewCassetteTransport opens path for mode. Replay reads the cassette now;
+// record appends to the cassette at path, if any, and rewrites path after
+// every new exchange.
+func NewCassetteTransport(path string, mode CassetteMode, opts CassetteOptions) (*CassetteTransport, error) {
+	t := &CassetteTransport{
+		path:   path,
+		mode:   mode,
+		match:  opts.Match,
+		redact: append(slices.Clone(redactedHeaders), opts.RedactHeaders...),
+		next:   opts.Transport,
+	}
+	if len(t.match) == 0 {
+		t.match = DefaultCassetteMatch
+	}
+	for _, rule := range t.match {
+		if !ValidCassetteMatch(rule) {
+			return nil, fmt.Errorf("unknown cassette match rule %q", rule)
+		}
+	}
+	if t.next == nil {
+		t.next = defaultTransport
+	}
+
+	switch mode {
+	case CassetteRecord:
+		if err := t.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	case CassetteReplay:
+		if err := t.load(); err != nil {
+			return nil, err
+		}
+		t.played = make([]bool, len(t.cassette.Interactions))
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	return t, nil
+}
+
+func (t *CassetteTransport) load() error {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &t.cassette); err != nil {
+		return fmt.Errorf("cassette %s: %w", t.path, err)
+	}
+	return nil
+}
+
+// ValidCassetteMatch reports whether rule is a known match rule.
+func ValidCassetteMatch(rule string) bool {
+	switch rule {
+	case "method", "host", "path", "query":
+		return true
+	}
+	name, ok := strings.CutPrefix(rule, "header:")
+	return ok && name != ""
+}
+
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == CassetteReplay {
+		return t.replay(req)
+	}
+	return t.record(req)
+}
+
+// replay answers with the first unplayed match, so repeated requests see
+// the recorded sequence, and then keeps repeating the last match.
+func (t *CassetteTransport) replay(req *http.Request) (*http.Response, error) {
+	live := t.sanitizeRequest(req)
+
+	t.mu.Lock()
+	found := -1
+	for i, interaction := range t.cassette.Interactions {
+		if !t.matches(live, interaction.Request) {
+			continue
+		}
+		found = i
+		if !t.played[i] {
+			break
+		}
+	}
+	if found >= 0 {
+		t.played[found] = true
+	}
+	t.mu.Unlock()
+
+	if found < 0 {
+		return nil, &CassetteMissError{Method: live.Method, URL: live.URL}
+	}
+	recorded := t.cassette.Interactions[found].Response
+	body := recorded.BodyBase64
+	if body == nil {
+		body = []byte(recorded.Body)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// record forwards req and saves the exchange with the body decoded, so
+// cassettes stay readable and editable.
+func (t *CassetteTransport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoded, err := decodedBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(decoded)
+	decoded.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := resp.Header.Clone()
+	headers.Del("Content-Encoding")
+	headers.Del("Content-Length")
+	recorded := RecordedResponse{Status: resp.StatusCode, Headers: t.redactHeaders(headers)}
+	if utf8.Valid(body) {
+		recorded.Body = string(body)
+	} else {
+		recorded.BodyBase64 = body
+	}
+
+	t.mu.Lock()
+	interaction := Interaction{
+		RecordedAt: time.Now().UTC(),
+		Request:    t.sanitizeRequest(req),
+		Response:   recorded,
+	}
+	if !t.repeatsLast(interaction) {
+		t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+		err = t.save()
+	}
+	t.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("saving cassette: %w", err)
+	}
+
+	resp.Header = headers
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// repeatsLast reports whether the last recorded match for the request of
+// interaction got the same response. Replay repeats the last match anyway,
+// so skipping it keeps a cassette recorded behind the catalog poller from
+// growing while the upstream answers the same. t.mu must be held.
+func (t *CassetteTransport) repeatsLast(interaction Interaction) bool {
+	for i := len(t.cassette.Interactions) - 1; i >= 0; i-- {
+		previous := t.cassette.Interactions[i]
+		if t.matches(interaction.Request, previous.Request) {
+			return sameResponse(previous.Response, interaction.Response)
+		}
+	}
+	return false
+}
+
+// sameResponse compares recorded responses, ignoring the Date header.
+func sameResponse(a, b RecordedResponse) bool {
+	if a.Status != b.Status || a.Body != b.Body || !bytes.Equal(a.BodyBase64, b.BodyBase64) {
+		return false
+	}
+	aHeaders, bHeaders := a.Headers.Clone(), b.Headers.Clone()
+	aHeaders.Del("Date")
+	bHeaders.Del("Date")
+	return maps.EqualFunc(aHeaders, bHeaders, slices.Equal[[]string])
+}
+
+// save rewrites the cassette through a temporary file, so a crash leaves
+// the previous version intact. t.mu must be held.
+func (t *CassetteTransport) save() error {
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), "."+filepath.Base(t.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path)
+}
+
+func (t *CassetteTransport) matches(live, recorded RecordedRequest) bool {
+	liveURL, err := url.Parse(live.URL)
+	if err != nil {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	for _, rule := range t.match {
+		var same bool
+		switch rule {
+		case "method":
+			same = live.Method == recorded.Method
+		case "host":
+			same = strings.EqualFold(liveURL.Host, recordedURL.Host)
+		case "path":
+			same = liveURL.Path == recordedURL.Path
+		case "query":
+			same = liveURL.Query().Encode() == recordedURL.Query().Encode()
+		default:
+			name := strings.TrimPrefix(rule, "header:")
+			same = slices.Equal(live.Headers.Values(name), recorded.Headers.Values(name))
+		}
+		if !same {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *CassetteTransport) sanitizeRequest(req *http.Request) RecordedRequest {
+	u := *req.URL
+	query := u.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		if slices.ContainsFunc(redactedQueryWords, func(word string) bool { return strings.Contains(lower, word) }) {
+			query[name] = []string{redacted}
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.User = nil
+	return RecordedRequest{Method: req.Method, URL: u.String(), Headers: t.redactHeaders(req.Header)}
+}
+
+// redactHeaders returns a copy of headers with secret values replaced.
+func (t *CassetteTransport) redactHeaders(headers http.Header) http.Header {
+	headers = headers.Clone()
+	for _, name := range t.redact {
+		values := headers.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		masked := make([]string, len(values))
+		for i := range masked {
+			masked[i] = redacted
+		}
+		headers[http.CanonicalHeaderKey(name)] = masked
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
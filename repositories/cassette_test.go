@@ -0,0 +1,303 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassetteTransport_RecordThenReplay(t *testing.T) {
+	// Arrange
+	mockBooks := []models.Book{{ID: 1, Name: "Test Book", Author: "Test Author", UnitsSold: 100, Price: 25}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Set-Cookie", "session=secret")
+		gz := gzip.NewWriter(w)
+		json.NewEncoder(gz).Encode(mockBooks)
+		gz.Close()
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := NewCassetteTransport(path, CassetteRecord, CassetteOptions{})
+	assert.NoError(t, err)
+	recording := NewExternalBooksRepositoryWithOptions(server.URL+"/books?token=abc&page=1", ExternalBooksOptions{Transport: recorder})
+
+	// Act
+	recorded, recordErr := recording.GetBooksProvider(context.Background())
+	server.Close()
+	player, err := NewCassetteTransport(path, CassetteReplay, CassetteOptions{})
+	assert.NoError(t, err)
+	replaying := NewExternalBooksRepositoryWithOptions("http://replay.invalid/books?page=1&token=other", ExternalBooksOptions{Transport: player})
+	replayed, replayErr := replaying.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, recordErr)
+	assert.Equal(t, mockBooks, recorded)
+	assert.NoError(t, replayErr)
+	assert.Equal(t, mockBooks, replayed)
+
+	var cassette Cassette
+	data, _ := os.ReadFile(path)
+	assert.NoError(t, json.Unmarshal(data, &cassette))
+	assert.Len(t, cassette.Interactions, 1)
+	interaction := cassette.Interactions[0]
+	assert.Equal(t, server.URL+"/books?page=1&token=REDACTED", interaction.Request.URL)
+	assert.Equal(t, "REDACTED", interaction.Response.Headers.Get("Set-Cookie"))
+	assert.Empty(t, interaction.Response.Headers.Get("Content-Encoding"))
+	assert.JSONEq(t, `[{"id":1,"name":"Test Book","author":"Test Author","units_sold":100,"price":25}]`, interaction.Response.Body)
+	assert.NotContains(t, string(data), "secret")
+}
+
+func TestCassetteTransport_RedactsRequestHeaders(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0xff, 0xfe})
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, _ := NewCassetteTransport(path, CassetteRecord, CassetteOptions{RedactHeaders: []string{"X-Tenant"}})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Accept", "application/json")
+
+	// Act
+	resp, err := recorder.RoundTrip(req)
+
+	// Assert
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+	data, _ := os.ReadFile(path)
+	var cassette Cassette
+	assert.NoError(t, json.Unmarshal(data, &cassette))
+	headers := cassette.Interactions[0].Request.Headers
+	assert.Equal(t, "REDACTED", headers.Get("Authorization"))
+	assert.Equal(t, "REDACTED", headers.Get("X-Tenant"))
+	assert.Equal(t, "application/json", headers.Get("Accept"))
+	assert.Equal(t, []byte{0xff, 0xfe}, cassette.Interactions[0].Response.BodyBase64)
+}
+
+func TestCassetteTransport_ReplaysInOrder(t *testing.T) {
+	// Arrange
+	player, err := NewCassetteTransport("testdata/cassettes/mockapi.json", CassetteReplay, CassetteOptions{})
+	assert.NoError(t, err)
+	repo := NewExternalBooksRepositoryWithOptions(DefaultBooksURL, ExternalBooksOptions{Transport: player})
+
+	// Act
+	first, firstErr := repo.GetBooksProvider(context.Background())
+	_, secondErr := repo.GetBooksProvider(context.Background())
+	_, thirdErr := repo.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, firstErr)
+	assert.Len(t, first, 3)
+	// The second capture has a price sent as a string; it keeps replaying.
+	assert.ErrorIs(t, secondErr, ErrUpstreamDecode)
+	assert.ErrorIs(t, thirdErr, ErrUpstreamDecode)
+}
+
+func TestCassetteTransport_Match(t *testing.T) {
+	cassette := Cassette{Interactions: []Interaction{{
+		Request:  RecordedRequest{Method: http.MethodGet, URL: "https://upstream.example/books?page=1", Headers: http.Header{"Accept": {"application/json"}}},
+		Response: RecordedResponse{Status: http.StatusOK, Body: "[]"},
+	}}}
+	data, _ := json.Marshal(cassette)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	os.WriteFile(path, data, 0o644)
+
+	tests := []struct {
+		name   string
+		match  []string
+		method string
+		url    string
+		hit    bool
+	}{
+		{"default ignores host", nil, http.MethodGet, "http://localhost/books?page=1", true},
+		{"default compares query", nil, http.MethodGet, "https://upstream.example/books?page=2", false},
+		{"default compares method", nil, http.MethodHead, "https://upstream.example/books?page=1", false},
+		{"host", []string{"host"}, http.MethodGet, "http://localhost/books?page=1", false},
+		{"path only", []string{"path"}, http.MethodGet, "http://localhost/books?page=9", true},
+		{"header", []string{"header:Accept"}, http.MethodGet, "http://localhost/other", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			player, err := NewCassetteTransport(path, CassetteReplay, CassetteOptions{Match: tt.match})
+			assert.NoError(t, err)
+			req, _ := http.NewRequest(tt.method, tt.url, nil)
+			req.Header.Set("Accept", "application/json")
+
+			// Act
+			resp, err := player.RoundTrip(req)
+
+			// Assert
+			if tt.hit {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				return
+			}
+			assert.ErrorIs(t, err, ErrCassetteMiss)
+			assert.ErrorIs(t, err, ErrServiceUnavailable)
+		})
+	}
+}
+
+func TestCassetteTransport_MissFailsRepository(t *testing.T) {
+	// Arrange
+	player, _ := NewCassetteTransport("testdata/cassettes/mockapi.json", CassetteReplay, CassetteOptions{Match: []string{"host"}})
+	repo := NewExternalBooksRepositoryWithOptions("http://localhost/books", ExternalBooksOptions{Transport: player})
+
+	// Act
+	books, err := repo.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.ErrorIs(t, err, ErrCassetteMiss)
+	assert.Nil(t, books)
+}
+
+func TestNewCassetteTransport_Errors(t *testing.T) {
+	malformed := filepath.Join(t.TempDir(), "malformed.json")
+	os.WriteFile(malformed, []byte("{"), 0o644)
+
+	tests := []struct {
+		name string
+		path string
+		mode CassetteMode
+		opts CassetteOptions
+		want string
+	}{
+		{"unknown mode", "cassette.json", "rewind", CassetteOptions{}, `unknown cassette mode "rewind"`},
+		{"unknown rule", "cassette.json", CassetteReplay, CassetteOptions{Match: []string{"body"}}, `unknown cassette match rule "body"`},
+		{"empty header rule", "cassette.json", CassetteRecord, CassetteOptions{Match: []string{"header:"}}, `unknown cassette match rule "header:"`},
+		{"missing cassette", filepath.Join(t.TempDir(), "missing.json"), CassetteReplay, CassetteOptions{}, "no such file"},
+		{"malformed cassette", malformed, CassetteReplay, CassetteOptions{}, "unexpected end of JSON input"},
+		{"malformed cassette to record", malformed, CassetteRecord, CassetteOptions{}, "unexpected end of JSON input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			transport, err := NewCassetteTransport(tt.path, tt.mode, tt.opts)
+
+			// Assert
+			assert.ErrorContains(t, err, tt.want)
+			assert.Nil(t, transport)
+		})
+	}
+}
+
+func TestCassetteTransport_RecordSavesEachExchange(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Query().Get("n")))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, _ := NewCassetteTransport(path, CassetteRecord, CassetteOptions{})
+	client := &http.Client{Transport: recorder}
+
+	// Act
+	for _, n := range []string{"1", "2"} {
+		resp, err := client.Get(server.URL + "?n=" + n)
+		assert.NoError(t, err)
+		var body bytes.Buffer
+		body.ReadFrom(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, n, body.String())
+	}
+
+	// Assert
+	var cassette Cassette
+	data, _ := os.ReadFile(path)
+	assert.NoError(t, json.Unmarshal(data, &cassette))
+	assert.Len(t, cassette.Interactions, 2)
+	assert.Equal(t, "2", cassette.Interactions[1].Response.Body)
+}
+
+func TestCassetteTransport_RecordSkipsRepeatedResponses(t *testing.T) {
+	// Arrange
+	answers := []string{"a", "a", "b", "b", "a", "a"}
+	served := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(answers[served]))
+		served++
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, _ := NewCassetteTransport(path, CassetteRecord, CassetteOptions{})
+	client := &http.Client{Transport: recorder}
+
+	// Act
+	for range answers {
+		resp, err := client.Get(server.URL + "/books")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Assert
+	var cassette Cassette
+	data, _ := os.ReadFile(path)
+	assert.NoError(t, json.Unmarshal(data, &cassette))
+	var bodies []string
+	for _, interaction := range cassette.Interactions {
+		bodies = append(bodies, interaction.Response.Body)
+	}
+	assert.Equal(t, []string{"a", "b", "a"}, bodies)
+}
+
+func TestCassetteTransport_RecordAppendsToExistingCassette(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	for _, route := range []string{"/first", "/second"} {
+		recorder, err := NewCassetteTransport(path, CassetteRecord, CassetteOptions{})
+		assert.NoError(t, err)
+
+		// Act
+		resp, err := (&http.Client{Transport: recorder}).Get(server.URL + route)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Assert
+	var cassette Cassette
+	data, _ := os.ReadFile(path)
+	assert.NoError(t, json.Unmarshal(data, &cassette))
+	assert.Len(t, cassette.Interactions, 2)
+	assert.Equal(t, "/first", cassette.Interactions[0].Response.Body)
+	assert.Equal(t, "/second", cassette.Interactions[1].Response.Body)
+}
+
+func TestCassetteTransport_RecordRejectsOversizedBodies(t *testing.T) {
+	// Arrange
+	compressed := encode(t, "gzip", make([]byte, maxDecodedBody+1))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed)
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, _ := NewCassetteTransport(path, CassetteRecord, CassetteOptions{})
+
+	// Act
+	_, err := (&http.Client{Transport: recorder}).Get(server.URL)
+
+	// Assert
+	assert.ErrorIs(t, err, errBodyTooLarge)
+	assert.NoFileExists(t, path)
+}
@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUpstreamAuth reports that no credentials could be obtained for the
+// upstream, for example because the token endpoint refused the client.
+var ErrUpstreamAuth = errors.New("external service authentication failed")
+
+// Credentials authorize upstream requests. Implementations must be safe for
+// concurrent use and must not reveal secrets through String or errors.
+type Credentials interface {
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// invalidator is implemented by Credentials that cache a token the upstream
+// may still reject, so the repository can drop it and retry once.
+type invalidator interface {
+	Invalidate()
+}
+
+type bearerToken struct{ token string }
+
+// BearerToken sends a fixed "Authorization: Bearer" token.
+func BearerToken(token string) Credentials { return bearerToken{token: token} }
+
+func (c bearerToken) Apply(_ context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return nil
+}
+
+func (bearerToken) String() string { return "bearer token" }
+
+type basicAuth struct{ username, password string }
+
+// BasicAuth sends HTTP Basic credentials.
+func BasicAuth(username, password string) Credentials {
+	return basicAuth{username: username, password: password}
+}
+
+func (c basicAuth) Apply(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(c.username, c.password)
+	return nil
+}
+
+func (c basicAuth) String() string { return "basic auth for " + c.username }
+
+type headerAPIKey struct{ header, key string }
+
+// HeaderAPIKey sends key in header, X-API-Key when header is empty.
+func HeaderAPIKey(header, key string) Credentials {
+	if header == "" {
+		header = "X-API-Key"
+	}
+	return headerAPIKey{header: header, key: key}
+}
+
+func (c headerAPIKey) Apply(_ context.Context, req *http.Request) error {
+	req.Header.Set(c.header, c.key)
+	return nil
+}
+
+func (c headerAPIKey) String() string { return "API key in " + c.header }
+
+// TokenError is a failed OAuth2 token request. Code and Description come
+// from the token endpoint's error response, when it sent one.
+type TokenError struct {
+	Status      int
+	Code        string
+	Description string
+	Err         error
+}
+
+func (e *TokenError) Error() string {
+	switch {
+	case e.Err != nil:
+		return fmt.Sprintf("%v: token request: %v", ErrUpstreamAuth, e.Err)
+	case e.Code != "":
+		return fmt.Sprintf("%v: token endpoint returned %d %s: %s", ErrUpstreamAuth, e.Status, e.Code, e.Description)
+	default:
+		return fmt.Sprintf("%v: token endpoint returned %d", ErrUpstreamAuth, e.Status)
+	}
+}
+
+func (e *TokenError) Unwrap() error { return e.Err }
+
+func (e *TokenError) Is(target error) bool { return target == ErrUpstreamAuth }
+
+// ClientCredentialsConfig identifies an OAuth2 client to its token endpoint.
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Client calls the token endpoint; nil means a client on the default
+	// transport with DefaultUpstreamTimeout.
+	Client *http.Client
+	// RefreshBefore renews a token this long before it expires; zero means
+	// one minute.
+	RefreshBefore time.Duration
+}
+
+// ClientCredentials gets bearer tokens with the OAuth2 client credentials
+// grant (RFC 6749, section 4.4) and caches each until shortly before it
+// expires. Concurrent requests share one token request.
+type ClientCredentials struct {
+	cfg ClientCredentialsConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewClientCredentials returns credentials that request a token on first use.
+func NewClientCredentials(cfg ClientCredentialsConfig) *ClientCredentials {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: DefaultUpstreamTimeout, Transport: defaultTransport}
+	}
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = time.Minute
+	}
+	return &ClientCredentials{cfg: cfg, now: time.Now}
+}
+
+func (c *ClientCredentials) Apply(ctx context.Context, req *http.Request) error {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the cached access token, requesting a new one when there is
+// none or it is about to expire.
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.expires.IsZero() || c.now().Add(c.cfg.RefreshBefore).Before(c.expires)) {
+		return c.token, nil
+	}
+
+	token, expiresIn, err := c.requestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.expires = time.Time{}
+	if expiresIn > 0 {
+		c.expires = c.now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Invalidate drops the cached token, for when the upstream rejects it.
+func (c *ClientCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+func (c *ClientCredentials) String() string {
+	return "OAuth2 client credentials for " + c.cfg.ClientID
+}
+
+func (c *ClientCredentials) requestToken(ctx context.Context) (string, int64, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, &TokenError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return "", 0, &TokenError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, &TokenError{Status: resp.StatusCode, Code: body.Error, Description: body.ErrorDescription}
+	}
+	if decodeErr != nil {
+		return "", 0, &TokenError{Status: resp.StatusCode, Err: decodeErr}
+	}
+	if body.AccessToken == "" || !strings.EqualFold(body.TokenType, "bearer") {
+		return "", 0, &TokenError{Status: resp.StatusCode, Err: fmt.Errorf("unsupported token type %q or missing access token", body.TokenType)}
+	}
+	return body.AccessToken, body.ExpiresIn, nil
+}
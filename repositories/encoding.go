@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding is sent upstream. Setting it by hand turns off the
+// transport's own gzip handling, so decodedBody handles every coding listed.
+const acceptEncoding = "br, zstd, gzip"
+
+// maxDecodedBody caps a decompressed upstream body.
+const maxDecodedBody = 64 << 20
+
+// errBodyTooLarge is returned by reads past maxDecodedBody, so a capped body
+// is never mistaken for a complete one.
+var errBodyTooLarge = fmt.Errorf("decoded body exceeds %d bytes", maxDecodedBody)
+
+// decodedBody returns resp.Body with its Content-Encoding removed. Closing
+// the result does not close resp.Body.
+func decodedBody(resp *http.Response) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	switch coding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); coding {
+	case "", "identity":
+		return newLimitedReadCloser(resp.Body, io.NopCloser(resp.Body), maxDecodedBody), nil
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		reader = gz
+	case "br":
+		reader = io.NopCloser(brotli.NewReader(resp.Body))
+	case "zstd":
+		decoder, err := zstd.NewReader(resp.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxDecodedBody))
+		if err != nil {
+			return nil, err
+		}
+		reader = decoder.IOReadCloser()
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", coding)
+	}
+	return newLimitedReadCloser(reader, reader, maxDecodedBody), nil
+}
+
+// limitedReadCloser reads up to limit bytes and fails with errBodyTooLarge
+// if more follow.
+type limitedReadCloser struct {
+	io.Closer
+	reader    io.Reader
+	remaining int64
+}
+
+func newLimitedReadCloser(reader io.Reader, closer io.Closer, limit int64) *limitedReadCloser {
+	return &limitedReadCloser{Closer: closer, reader: reader, remaining: limit}
+}
+
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if r.remaining <= 0 {
+		n, err := r.reader.Read(p[:1])
+		if n > 0 {
+			return 0, errBodyTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
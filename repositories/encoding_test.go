@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func encode(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	case "zstd":
+		encoder, err := zstd.NewWriter(&buf)
+		assert.NoError(t, err)
+		writer = encoder
+	default:
+		return data
+	}
+	_, err := writer.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestExternalBooksRepository_DecodesCompressedResponses(t *testing.T) {
+	mockBooks := []models.Book{
+		{ID: 1, Name: "Test Book", Author: "Test Author", UnitsSold: 100, Price: 25},
+	}
+	payload, _ := json.Marshal(mockBooks)
+
+	for _, encoding := range []string{"", "gzip", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			// Arrange
+			var acceptEncoding string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				acceptEncoding = r.Header.Get("Accept-Encoding")
+				if encoding != "" {
+					w.Header().Set("Content-Encoding", encoding)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(encode(t, encoding, payload))
+			}))
+			defer server.Close()
+			repo := NewExternalBooksRepository(server.URL)
+
+			// Act
+			books, err := repo.GetBooksProvider(context.Background())
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, mockBooks, books)
+			assert.Equal(t, "br, zstd, gzip", acceptEncoding)
+		})
+	}
+}
+
+func TestExternalBooksRepository_RejectsBadEncodings(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{"unknown coding", "compress", []byte("[]")},
+		{"corrupt gzip", "gzip", []byte("not gzip")},
+		{"corrupt zstd", "zstd", []byte("not zstd")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", tt.encoding)
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+			repo := NewExternalBooksRepository(server.URL)
+
+			// Act
+			books, err := repo.GetBooksProvider(context.Background())
+
+			// Assert
+			assert.ErrorIs(t, err, ErrUpstreamDecode)
+			assert.Nil(t, books)
+		})
+	}
+}
+
+func TestLimitedReadCloser(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{"under the limit", "abc", nil},
+		{"at the limit", "abcd", nil},
+		{"over the limit", "abcde", errBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			reader := strings.NewReader(tt.body)
+			limited := newLimitedReadCloser(reader, io.NopCloser(reader), 4)
+
+			// Act
+			data, err := io.ReadAll(limited)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.body, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+var (
+	ErrServiceUnavailable = errors.New("external service failure")
+	ErrUpstreamStatus     = errors.New("external service returned status")
+	ErrUpstreamDecode     = errors.New("external service returned an invalid body")
+)
+
+// UpstreamStatusError reports a non-200 answer from the external service.
+type UpstreamStatusError struct {
+	Code int
+}
+
+func (e *UpstreamStatusError) Error() string {
+	return fmt.Sprintf("%v %d", ErrUpstreamStatus, e.Code)
+}
+
+func (e *UpstreamStatusError) Is(target error) bool { return target == ErrUpstreamStatus }
+
+// UpstreamTimeoutError reports that the external service did not answer in
+// time. It is also an ErrServiceUnavailable.
+type UpstreamTimeoutError struct {
+	Err error
+}
+
+func (e *UpstreamTimeoutError) Error() string {
+	return fmt.Sprintf("external service timed out: %v", e.Err)
+}
+
+func (e *UpstreamTimeoutError) Unwrap() error { return e.Err }
+
+func (e *UpstreamTimeoutError) Is(target error) bool { return target == ErrServiceUnavailable }
+
+// UpstreamDecodeError reports a response body that is not a book list.
+type UpstreamDecodeError struct {
+	Err error
+}
+
+func (e *UpstreamDecodeError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrUpstreamDecode, e.Err)
+}
+
+func (e *UpstreamDecodeError) Unwrap() error { return e.Err }
+
+func (e *UpstreamDecodeError) Is(target error) bool { return target == ErrUpstreamDecode }
+
+// transportError classifies an error returned by http.Client.Do.
+func transportError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &UpstreamTimeoutError{Err: err}
+	}
+	return fmt.Errorf("%w: %w", ErrServiceUnavailable, err)
+}
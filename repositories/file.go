@@ -0,0 +1,360 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"educabot.com/bookshop/models"
+)
+
+type FileFormat string
+
+const (
+	FileFormatJSON   FileFormat = "json"
+	FileFormatNDJSON FileFormat = "ndjson"
+	FileFormatCSV    FileFormat = "csv"
+)
+
+// csvColumns maps normalized CSV headers to book fields. The export headers
+// (ID, Title, Author, ISBN, Units sold, Price) are included, so exported
+// files load back unchanged.
+var csvColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"title":      "name",
+	"author":     "author",
+	"isbn":       "isbn",
+	"units_sold": "units_sold",
+	"units":      "units_sold",
+	"sales":      "units_sold",
+	"price":      "price",
+}
+
+var requiredCSVColumns = []string{"id", "name", "author"}
+
+// FileParseError reports where a books file stops being valid. Line and
+// Column are 1-based. It is also an ErrUpstreamDecode.
+type FileParseError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *FileParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+}
+
+func (e *FileParseError) Unwrap() error { return e.Err }
+
+func (e *FileParseError) Is(target error) bool { return target == ErrUpstreamDecode }
+
+// FileBooksRepository serves a catalog read from a JSON array, NDJSON or CSV
+// file. Reload and Watch pick up changes; a change that fails to parse is
+// reported and the last good catalog keeps being served.
+type FileBooksRepository struct {
+	path   string
+	format FileFormat
+
+	mu      sync.RWMutex
+	books   []models.Book
+	isbns   *ISBNIndex
+	modTime time.Time
+	size    int64
+}
+
+// NewFileBooksRepository loads path once. An empty format is taken from the
+// extension (.json, .ndjson or .jsonl, .csv) or else from the content: '['
+// starts a JSON array, '{' starts NDJSON and anything else is CSV with a
+// header row.
+func NewFileBooksRepository(path string, format FileFormat) (*FileBooksRepository, error) {
+	r := &FileBooksRepository{path: path, format: format}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetBooksProvider returns the loaded catalog, which callers must treat as
+// read-only.
+func (r *FileBooksRepository) GetBooksProvider(_ context.Context) ([]models.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.books, nil
+}
+
+// ISBNIndex returns the index of the loaded catalog, built when it was
+// loaded.
+func (r *FileBooksRepository) ISBNIndex(_ context.Context) (*ISBNIndex, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.isbns, nil
+}
+
+// Reload reads the file again when its size or modification time changed
+// and reports whether the catalog was replaced.
+func (r *FileBooksRepository) Reload() (bool, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	unchanged := r.books != nil && info.ModTime().Equal(r.modTime) && info.Size() == r.size
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return false, err
+	}
+	books, err := parseBooksFile(r.path, data, r.format)
+	if err != nil {
+		return false, err
+	}
+
+	isbns := indexISBNs(books)
+	r.mu.Lock()
+	r.books, r.isbns = books, isbns
+	r.modTime = info.ModTime()
+	r.size = info.Size()
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch polls the file every interval until ctx is done. Polling, unlike
+// inotify, also sees changes on network and bind-mounted filesystems.
+func (r *FileBooksRepository) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Reload(); err != nil {
+				slog.Warn("books file not reloaded", slog.String("path", r.path), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// LastRefresh is the modification time of the loaded file.
+func (r *FileBooksRepository) LastRefresh() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.modTime
+}
+
+// TTL is zero: the file may change at any time, so clients revalidate.
+func (r *FileBooksRepository) TTL() time.Duration {
+	return 0
+}
+
+func parseBooksFile(path string, data []byte, format FileFormat) ([]models.Book, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 {
+		return nil, &FileParseError{Path: path, Line: 1, Column: 1, Err: errors.New("file is empty")}
+	}
+	if format == "" {
+		format = detectFileFormat(path, trimmed[0])
+	}
+
+	switch format {
+	case FileFormatJSON:
+		return parseJSONBooks(path, data)
+	case FileFormatNDJSON:
+		return parseNDJSONBooks(path, data)
+	case FileFormatCSV:
+		return parseCSVBooks(path, data)
+	default:
+		return nil, fmt.Errorf("unknown books file format %q", format)
+	}
+}
+
+func detectFileFormat(path string, first byte) FileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FileFormatJSON
+	case ".ndjson", ".jsonl":
+		return FileFormatNDJSON
+	case ".csv":
+		return FileFormatCSV
+	}
+	switch first {
+	case '[':
+		return FileFormatJSON
+	case '{':
+		return FileFormatNDJSON
+	default:
+		return FileFormatCSV
+	}
+}
+
+func parseJSONBooks(path string, data []byte) ([]models.Book, error) {
+	var books []models.Book
+	if err := json.Unmarshal(data, &books); err != nil {
+		line, column := position(data, jsonErrorOffset(err, len(data)))
+		return nil, &FileParseError{Path: path, Line: line, Column: column, Err: err}
+	}
+	if books == nil {
+		books = []models.Book{}
+	}
+	return books, nil
+}
+
+// parseNDJSONBooks reads one book per line; blank lines are skipped.
+func parseNDJSONBooks(path string, data []byte) ([]models.Book, error) {
+	books := []models.Book{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		if len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		var book models.Book
+		if err := json.Unmarshal(text, &book); err != nil {
+			_, column := position(text, jsonErrorOffset(err, len(text)))
+			return nil, &FileParseError{Path: path, Line: line, Column: column, Err: err}
+		}
+		books = append(books, book)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func parseCSVBooks(path string, data []byte) ([]models.Book, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	parseErr := func(err error) error {
+		var csvErr *csv.ParseError
+		if errors.As(err, &csvErr) {
+			return &FileParseError{Path: path, Line: csvErr.Line, Column: csvErr.Column, Err: csvErr.Err}
+		}
+		return err
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, parseErr(err)
+	}
+	fields := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, title := range header {
+		field := csvColumns[strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(title)))]
+		if field != "" && seen[field] {
+			line, column := reader.FieldPos(i)
+			return nil, &FileParseError{Path: path, Line: line, Column: column, Err: fmt.Errorf("column %q repeats %s", title, field)}
+		}
+		fields[i], seen[field] = field, true
+	}
+	for _, required := range requiredCSVColumns {
+		if !seen[required] {
+			return nil, &FileParseError{Path: path, Line: 1, Column: 1, Err: fmt.Errorf("missing %s column", required)}
+		}
+	}
+
+	books := []models.Book{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return books, nil
+		}
+		if err != nil {
+			return nil, parseErr(err)
+		}
+
+		var book models.Book
+		for i, value := range record {
+			if err := setBookField(&book, fields[i], value); err != nil {
+				line, column := reader.FieldPos(i)
+				return nil, &FileParseError{Path: path, Line: line, Column: column, Err: fmt.Errorf("%s: %w", header[i], err)}
+			}
+		}
+		books = append(books, book)
+	}
+}
+
+func setBookField(book *models.Book, field, value string) error {
+	switch field {
+	case "id", "units_sold", "price":
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return fmt.Errorf("%q is not a non-negative integer", value)
+		}
+		switch field {
+		case "id":
+			book.ID = uint(n)
+		case "units_sold":
+			book.UnitsSold = uint(n)
+		default:
+			book.Price = uint(n)
+		}
+	case "name":
+		book.Name = unneutralize(value)
+	case "author":
+		book.Author = unneutralize(value)
+	case "isbn":
+		book.ISBN = strings.TrimSpace(value)
+	}
+	return nil
+}
+
+// unneutralize drops the apostrophe CSV exports put before text a
+// spreadsheet would read as a formula.
+func unneutralize(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// jsonErrorOffset is the byte offset encoding/json reports for err, or the
+// end of the input when it reports none.
+func jsonErrorOffset(err error, size int) int64 {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Offset
+	}
+	return int64(size)
+}
+
+// position converts a byte offset in data to a 1-based line and column. An
+// offset just past an error points at the byte that caused it.
+func position(data []byte, offset int64) (int, int) {
+	if offset > 0 {
+		offset--
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeBooksFile writes content to name in a fresh directory.
+func writeBooksFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFileBooksRepository_Formats(t *testing.T) {
+	expected, _ := mockImpls.NewMockBooksRepositories().GetBooksProvider(context.Background())
+
+	for _, name := range []string{"books.json", "books.ndjson", "books.csv"} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			repository, err := NewFileBooksRepository(filepath.Join("testdata", name), "")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			// Act
+			books, err := repository.GetBooksProvider(context.Background())
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, expected, books)
+		})
+	}
+}
+
+func TestFileBooksRepository_CSVHeaderMapping(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, "books.txt", "\xef\xbb\xbfAuthor,Title,Sales,id,Notes\n"+
+		"\"Hunt, Andrew\",'=Pragmatic,13000,3,ignored\n"+
+		"Alan Donovan,Go,,1,\n")
+
+	// Act
+	repository, err := NewFileBooksRepository(path, "")
+
+	// Assert
+	if assert.NoError(t, err) {
+		books, _ := repository.GetBooksProvider(context.Background())
+		assert.Equal(t, []models.Book{
+			{ID: 3, Name: "=Pragmatic", Author: "Hunt, Andrew", UnitsSold: 13000},
+			{ID: 1, Name: "Go", Author: "Alan Donovan"},
+		}, books)
+	}
+}
+
+func TestFileBooksRepository_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		format  FileFormat
+		line    int
+		column  int
+	}{
+		{"json syntax", "books.json", "[\n  {\"id\": 1,}\n]", "", 2, 12},
+		{"json type", "books.json", "[\n  {\"id\": \"one\"}\n]", "", 2, 14},
+		{"json truncated", "books.json", "[\n  {\"id\": 1}", "", 2, 11},
+		{"ndjson", "books.ndjson", "{\"id\": 1}\n\n{\"id\": -2}\n", "", 3, 9},
+		{"csv value", "books.csv", "id,name,author,price\n1,Go,Alan,40\n2,Code,Bob,cheap\n", "", 3, 12},
+		{"csv quotes", "books.csv", "id,name,author\n1,\"Go,Alan\n", "", 2, 12},
+		{"csv field count", "books.csv", "id,name,author\n1,Go\n", "", 2, 1},
+		{"csv missing column", "books.csv", "id,title\n1,Go\n", "", 1, 1},
+		{"csv repeated column", "books.csv", "id,name,title,author\n", "", 1, 9},
+		{"empty", "books.json", " \n", "", 1, 1},
+		{"forced format", "books.json", "[]", FileFormatCSV, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			path := writeBooksFile(t, tt.file, tt.content)
+
+			// Act
+			_, err := NewFileBooksRepository(path, tt.format)
+
+			// Assert
+			var parseErr *FileParseError
+			if assert.ErrorAs(t, err, &parseErr) {
+				assert.Equal(t, path, parseErr.Path)
+				assert.Equal(t, tt.line, parseErr.Line, err.Error())
+				assert.Equal(t, tt.column, parseErr.Column, err.Error())
+			}
+			assert.ErrorIs(t, err, ErrUpstreamDecode)
+		})
+	}
+}
+
+func TestFileBooksRepository_MissingFile(t *testing.T) {
+	// Act
+	_, err := NewFileBooksRepository(filepath.Join(t.TempDir(), "missing.json"), "")
+
+	// Assert
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFileBooksRepository_Reload(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, "books.json", `[{"id": 1, "name": "Go", "author": "Alan"}]`)
+	repository, err := NewFileBooksRepository(path, "")
+	assert.NoError(t, err)
+	firstModified := repository.LastRefresh()
+	later := firstModified.Add(time.Second)
+
+	// Act
+	unchanged, unchangedErr := repository.Reload()
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"id": 1, "name": "Go", "author": "Alan"}, {"id": 2, "name": "C", "author": "Brian"}]`), 0o644))
+	assert.NoError(t, os.Chtimes(path, later, later))
+	reloaded, reloadErr := repository.Reload()
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"id": 3,`), 0o644))
+	broken, brokenErr := repository.Reload()
+	books, _ := repository.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.False(t, unchanged)
+	assert.NoError(t, unchangedErr)
+	assert.True(t, reloaded)
+	assert.NoError(t, reloadErr)
+	assert.False(t, broken)
+	assert.ErrorIs(t, brokenErr, ErrUpstreamDecode)
+	assert.Len(t, books, 2)
+	assert.True(t, repository.LastRefresh().Equal(later))
+	assert.Zero(t, repository.TTL())
+}
+
+func TestFileBooksRepository_ISBNIndex(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, "books.json", `[{"id": 1, "name": "Clean Code", "author": "Robert", "isbn": "0132350882"}]`)
+	repository, err := NewFileBooksRepository(path, "")
+	assert.NoError(t, err)
+	first, _ := repository.ISBNIndex(context.Background())
+	later := repository.LastRefresh().Add(time.Second)
+
+	// Act
+	unchanged, _ := repository.ISBNIndex(context.Background())
+	assert.NoError(t, os.WriteFile(path, []byte(`[{"id": 2, "name": "Pragmatic", "author": "Andrew", "isbn": "9780201616224"}, {"id": 3, "name": "Broken", "author": "X", "isbn": "123"}]`), 0o644))
+	assert.NoError(t, os.Chtimes(path, later, later))
+	_, reloadErr := repository.Reload()
+	reloaded, _ := repository.ISBNIndex(context.Background())
+
+	// Assert
+	assert.NoError(t, reloadErr)
+	assert.Same(t, first, unchanged)
+	books, _ := first.Lookup("9780132350884")
+	assert.Len(t, books, 1)
+	books, _ = reloaded.Lookup("9780132350884")
+	assert.Empty(t, books)
+	books, _ = reloaded.Lookup("020161622X")
+	assert.Len(t, books, 1)
+	assert.Equal(t, []models.Book{{ID: 3, Name: "Broken", Author: "X", ISBN: "123"}}, reloaded.Rejected())
+}
+
+func TestFileBooksRepository_Watch(t *testing.T) {
+	// Arrange
+	path := writeBooksFile(t, "books.ndjson", `{"id": 1, "name": "Go", "author": "Alan"}`)
+	repository, err := NewFileBooksRepository(path, "")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		repository.Watch(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	// Act
+	assert.NoError(t, os.WriteFile(path, []byte("{\"id\": 1, \"name\": \"Go\", \"author\": \"Alan\"}\n{\"id\": 2, \"name\": \"C\", \"author\": \"Brian\"}\n"), 0o644))
+
+	// Assert
+	assert.Eventually(t, func() bool {
+		books, _ := repository.GetBooksProvider(context.Background())
+		return len(books) == 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not stop after cancel")
+	}
+}
+
+func TestDetectFileFormat(t *testing.T) {
+	assert.Equal(t, FileFormatJSON, detectFileFormat("books.JSON", '{'))
+	assert.Equal(t, FileFormatNDJSON, detectFileFormat("books.jsonl", '['))
+	assert.Equal(t, FileFormatCSV, detectFileFormat("books.csv", '['))
+	assert.Equal(t, FileFormatJSON, detectFileFormat("books", '['))
+	assert.Equal(t, FileFormatNDJSON, detectFileFormat("books.txt", '{'))
+	assert.Equal(t, FileFormatCSV, detectFileFormat("books.txt", 'i'))
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"educabot.com/bookshop/models"
+)
+
+type ISBNDuplicate struct {
+	ISBN  string        `json:"isbn"`
+	Books []models.Book `json:"books"`
+}
+
+// ISBNIndex is a secondary index over a catalog keyed by normalized ISBN-13.
+// Books without an ISBN, or with one that fails validation, are left out;
+// the latter are listed by Rejected.
+type ISBNIndex struct {
+	byISBN   map[string][]models.Book
+	rejected []models.Book
+}
+
+func NewISBNIndex(books []models.Book) *ISBNIndex {
+	index := &ISBNIndex{byISBN: make(map[string][]models.Book)}
+	for _, book := range books {
+		isbn, err := models.NormalizeISBN(book.ISBN)
+		if err != nil {
+			if book.ISBN != "" {
+				index.rejected = append(index.rejected, book)
+			}
+			continue
+		}
+		book.ISBN = isbn
+		index.byISBN[isbn] = append(index.byISBN[isbn], book)
+	}
+	return index
+}
+
+// ISBNIndexer is implemented by repositories that keep an ISBN index of
+// their current catalog, rebuilt only when the catalog changes.
+type ISBNIndexer interface {
+	ISBNIndex(ctx context.Context) (*ISBNIndex, error)
+}
+
+// indexISBNs builds the index of a new catalog and logs the books it left
+// out, once per catalog.
+func indexISBNs(books []models.Book) *ISBNIndex {
+	index := NewISBNIndex(books)
+	if len(index.rejected) > 0 {
+		ids := make([]uint, len(index.rejected))
+		for i, book := range index.rejected {
+			ids[i] = book.ID
+		}
+		slog.Warn("books with an invalid ISBN left out of the ISBN index", slog.Int("count", len(ids)), slog.Any("ids", ids))
+	}
+	return index
+}
+
+// Lookup returns every book sharing the given ISBN. The input is normalized
+// first, so ISBN-10 and hyphenated forms match their ISBN-13 equivalent.
+func (i *ISBNIndex) Lookup(isbn string) ([]models.Book, error) {
+	normalized, err := models.NormalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+	return i.byISBN[normalized], nil
+}
+
+// Rejected returns the books left out because their ISBN is invalid.
+func (i *ISBNIndex) Rejected() []models.Book {
+	return i.rejected
+}
+
+func (i *ISBNIndex) Duplicates() []ISBNDuplicate {
+	duplicates := []ISBNDuplicate{}
+	for isbn, books := range i.byISBN {
+		if len(books) > 1 {
+			duplicates = append(duplicates, ISBNDuplicate{ISBN: isbn, Books: books})
+		}
+	}
+	sort.Slice(duplicates, func(a, b int) bool {
+		return duplicates[a].ISBN < duplicates[b].ISBN
+	})
+	return duplicates
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISBNIndex_Lookup(t *testing.T) {
+	// Arrange
+	index := NewISBNIndex([]models.Book{
+		{ID: 1, Name: "Clean Code", ISBN: "978-0132350884"},
+		{ID: 2, Name: "The Pragmatic Programmer", ISBN: "020161622X"},
+		{ID: 3, Name: "No ISBN"},
+	})
+
+	// Act
+	books, err := index.Lookup("0-201-61622-X")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, uint(2), books[0].ID)
+	assert.Equal(t, "9780201616224", books[0].ISBN)
+}
+
+func TestISBNIndex_Lookup_InvalidISBN(t *testing.T) {
+	// Arrange
+	index := NewISBNIndex(nil)
+
+	// Act
+	books, err := index.Lookup("not-an-isbn")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, books)
+}
+
+func TestISBNIndex_Lookup_SkipsInvalidCatalogEntries(t *testing.T) {
+	// Arrange
+	index := NewISBNIndex([]models.Book{
+		{ID: 1, ISBN: "9780132350885"},
+	})
+
+	// Act
+	books, err := index.Lookup("9780132350884")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, books)
+}
+
+func TestISBNIndex_Rejected(t *testing.T) {
+	// Arrange
+	index := NewISBNIndex([]models.Book{
+		{ID: 1, ISBN: "9780132350885"},
+		{ID: 2},
+		{ID: 3, ISBN: "9780132350884"},
+		{ID: 4, ISBN: "not an isbn"},
+	})
+
+	// Act
+	rejected := index.Rejected()
+
+	// Assert
+	assert.Equal(t, []models.Book{{ID: 1, ISBN: "9780132350885"}, {ID: 4, ISBN: "not an isbn"}}, rejected)
+}
+
+func TestISBNIndex_Duplicates(t *testing.T) {
+	// Arrange
+	index := NewISBNIndex([]models.Book{
+		{ID: 1, ISBN: "9780201616224"},
+		{ID: 2, ISBN: "0-201-61622-X"},
+		{ID: 3, ISBN: "9780132350884"},
+	})
+
+	// Act
+	duplicates := index.Duplicates()
+
+	// Assert
+	assert.Len(t, duplicates, 1)
+	assert.Equal(t, "9780201616224", duplicates[0].ISBN)
+	assert.Len(t, duplicates[0].Books, 2)
+}
@@ -16,8 +16,8 @@ func NewMockBooksRepositories() *MockBooksRepositories {
 
 func (m *MockBooksRepositories) GetBooksProvider(_ context.Context) ([]models.Book, error) {
 	return []models.Book{
-		{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40},
-		{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
-		{ID: 3, Name: "The Pragmatic Programmer", Author: "Andrew Hunt", UnitsSold: 13000, Price: 45},
+		{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40, ISBN: "978-0134190440"},
+		{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50, ISBN: "978-0132350884"},
+		{ID: 3, Name: "The Pragmatic Programmer", Author: "Andrew Hunt", UnitsSold: 13000, Price: 45, ISBN: "0-201-61622-X"},
 	}, nil
 }
@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"educabot.com/bookshop/models"
+)
+
+// CatalogSnapshot is a catalog as one refresh fetched it. Snapshots are
+// shared, so callers must treat Books as read-only.
+type CatalogSnapshot struct {
+	Books []models.Book
+	// Version counts the refreshes that changed the catalog, starting at 1.
+	Version uint64
+	// ChangedAt is when the refresh that produced Version ran; RefreshedAt
+	// is the last refresh, which may have found the catalog unchanged.
+	ChangedAt   time.Time
+	RefreshedAt time.Time
+	// ISBNs indexes Books; it is built once per Version.
+	ISBNs *ISBNIndex
+}
+
+// BookChange is a book whose ID was kept but whose fields changed.
+type BookChange struct {
+	Before models.Book `json:"before"`
+	After  models.Book `json:"after"`
+}
+
+// CatalogChange is what a refresh changed, with books matched by ID.
+type CatalogChange struct {
+	Version uint64        `json:"version"`
+	At      time.Time     `json:"at"`
+	Added   []models.Book `json:"added,omitempty"`
+	Removed []models.Book `json:"removed,omitempty"`
+	Changed []BookChange  `json:"changed,omitempty"`
+}
+
+// Empty reports whether the catalogs compared were the same.
+func (c CatalogChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// DiffCatalogs compares two catalogs by book ID. Added and Changed follow
+// the order of after, Removed the order of before. When an ID repeats, its
+// last book counts.
+func DiffCatalogs(before, after []models.Book) CatalogChange {
+	previous := byID(before)
+	current := byID(after)
+
+	var change CatalogChange
+	seen := make(map[uint]bool, len(current))
+	for _, book := range after {
+		if seen[book.ID] {
+			continue
+		}
+		seen[book.ID] = true
+		book = current[book.ID]
+		old, ok := previous[book.ID]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, book)
+		case old != book:
+			change.Changed = append(change.Changed, BookChange{Before: old, After: book})
+		}
+	}
+	for _, book := range before {
+		if _, ok := current[book.ID]; !ok && !seen[book.ID] {
+			seen[book.ID] = true
+			change.Removed = append(change.Removed, previous[book.ID])
+		}
+	}
+	return change
+}
+
+func byID(books []models.Book) map[uint]models.Book {
+	index := make(map[uint]models.Book, len(books))
+	for _, book := range books {
+		index[book.ID] = book
+	}
+	return index
+}
+
+// RefreshStatus describes the latest refreshes. Err is the error of the
+// last attempt, nil when it succeeded.
+type RefreshStatus struct {
+	LastSuccess time.Time
+	LastAttempt time.Time
+	Err         error
+}
+
+// CatalogRefresher fetches the catalog from the wrapped repository in the
+// background and serves the last good snapshot, so requests never wait on
+// the upstream once the first fetch succeeded. Each refresh that changes the
+// catalog is published to subscribers as a CatalogChange.
+type CatalogRefresher struct {
+	repository BooksRepository
+	interval   time.Duration
+	now        func() time.Time
+
+	snapshot  atomic.Pointer[CatalogSnapshot]
+	refreshMu sync.Mutex
+
+	mu          sync.Mutex
+	status      RefreshStatus
+	subscribers map[chan CatalogChange]struct{}
+	stopped     bool
+}
+
+func NewCatalogRefresher(repository BooksRepository, interval time.Duration) *CatalogRefresher {
+	return &CatalogRefresher{
+		repository:  repository,
+		interval:    interval,
+		now:         time.Now,
+		subscribers: make(map[chan CatalogChange]struct{}),
+	}
+}
+
+// GetBooksProvider returns the current snapshot. Until a refresh succeeds it
+// refreshes on the caller's behalf and returns that refresh's error.
+func (r *CatalogRefresher) GetBooksProvider(ctx context.Context) ([]models.Book, error) {
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot.Books, nil
+	}
+
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot.Books, nil
+	}
+	if _, err := r.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return r.snapshot.Load().Books, nil
+}
+
+// ISBNIndex returns the index of the current snapshot, refreshing first
+// like GetBooksProvider when there is none.
+func (r *CatalogRefresher) ISBNIndex(ctx context.Context) (*ISBNIndex, error) {
+	if _, err := r.GetBooksProvider(ctx); err != nil {
+		return nil, err
+	}
+	return r.snapshot.Load().ISBNs, nil
+}
+
+// Snapshot returns the current catalog, or nil before the first successful
+// refresh.
+func (r *CatalogRefresher) Snapshot() *CatalogSnapshot {
+	return r.snapshot.Load()
+}
+
+// Refresh fetches the catalog now. On success the snapshot is swapped when
+// the catalog changed and the change is published; on failure the previous
+// snapshot keeps being served.
+func (r *CatalogRefresher) Refresh(ctx context.Context) (CatalogChange, error) {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	return r.refresh(ctx)
+}
+
+func (r *CatalogRefresher) refresh(ctx context.Context) (CatalogChange, error) {
+	books, err := r.repository.GetBooksProvider(ctx)
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up, which says nothing about the upstream.
+		return CatalogChange{}, err
+	}
+	now := r.now()
+	r.mu.Lock()
+	r.status.LastAttempt, r.status.Err = now, err
+	if err == nil {
+		r.status.LastSuccess = now
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return CatalogChange{}, err
+	}
+	if books == nil {
+		books = []models.Book{}
+	}
+
+	previous := r.snapshot.Load()
+	var before []models.Book
+	version := uint64(1)
+	if previous != nil {
+		before, version = previous.Books, previous.Version+1
+	}
+	change := DiffCatalogs(before, books)
+	if previous != nil && change.Empty() {
+		r.snapshot.Store(&CatalogSnapshot{Books: previous.Books, Version: previous.Version, ChangedAt: previous.ChangedAt, RefreshedAt: now, ISBNs: previous.ISBNs})
+		return CatalogChange{Version: previous.Version, At: now}, nil
+	}
+
+	change.Version, change.At = version, now
+	r.snapshot.Store(&CatalogSnapshot{Books: books, Version: version, ChangedAt: now, RefreshedAt: now, ISBNs: indexISBNs(books)})
+	r.publish(change)
+	return change, nil
+}
+
+// Status reports when the catalog was last fetched and how that went.
+// Refreshes cancelled by their caller are not recorded.
+func (r *CatalogRefresher) Status() RefreshStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Subscribe returns a channel that receives every later CatalogChange, and a
+// function that cancels the subscription. Changes are not waited for: when
+// the channel's buffer is full the change is dropped, so subscribers that
+// need every change should compare the Version they receive. The channel is
+// closed when the subscription is cancelled or Run returns.
+func (r *CatalogRefresher) Subscribe(buffer int) (<-chan CatalogChange, func()) {
+	ch := make(chan CatalogChange, buffer)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		close(ch)
+		return ch, func() {}
+	}
+	r.subscribers[ch] = struct{}{}
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subscribers[ch]; ok {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (r *CatalogRefresher) publish(change CatalogChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ch := range r.subscribers {
+		select {
+		case ch <- change:
+		default:
+			slog.Warn("catalog change dropped for a slow subscriber", slog.Uint64("version", change.Version))
+		}
+	}
+}
+
+// Run refreshes every interval until ctx is done, then closes the
+// subscriptions. It does not refresh on entry; call Refresh first to load
+// the catalog at startup.
+func (r *CatalogRefresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	defer r.stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			change, err := r.Refresh(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				slog.Warn("catalog not refreshed", slog.String("error", err.Error()))
+			case err == nil && !change.Empty():
+				slog.Info("catalog changed", slog.Uint64("version", change.Version),
+					slog.Int("added", len(change.Added)), slog.Int("removed", len(change.Removed)), slog.Int("changed", len(change.Changed)))
+			}
+		}
+	}
+}
+
+func (r *CatalogRefresher) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	for ch := range r.subscribers {
+		delete(r.subscribers, ch)
+		close(ch)
+	}
+}
+
+// LastRefresh is when the catalog last changed, so Last-Modified and
+// If-Modified-Since survive refreshes that find nothing new.
+func (r *CatalogRefresher) LastRefresh() time.Time {
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot.ChangedAt
+	}
+	return time.Time{}
+}
+
+// TTL runs from LastRefresh to the next refresh, when the snapshot may be
+// replaced.
+func (r *CatalogRefresher) TTL() time.Duration {
+	snapshot := r.snapshot.Load()
+	if snapshot == nil {
+		return r.interval
+	}
+	return snapshot.RefreshedAt.Add(r.interval).Sub(snapshot.ChangedAt)
+}
@@ -0,0 +1,339 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCatalogs(t *testing.T) {
+	goBook := models.Book{ID: 1, Name: "The Go Programming Language", Price: 40}
+	cleanCode := models.Book{ID: 2, Name: "Clean Code", Price: 50}
+	pragmatic := models.Book{ID: 3, Name: "The Pragmatic Programmer", Price: 45}
+	discounted := models.Book{ID: 2, Name: "Clean Code", Price: 35}
+
+	tests := []struct {
+		name   string
+		before []models.Book
+		after  []models.Book
+		want   CatalogChange
+	}{
+		{"first catalog", nil, []models.Book{goBook, cleanCode}, CatalogChange{Added: []models.Book{goBook, cleanCode}}},
+		{"unchanged", []models.Book{goBook, cleanCode}, []models.Book{cleanCode, goBook}, CatalogChange{}},
+		{"added", []models.Book{goBook}, []models.Book{pragmatic, goBook}, CatalogChange{Added: []models.Book{pragmatic}}},
+		{"removed", []models.Book{goBook, cleanCode, pragmatic}, []models.Book{goBook}, CatalogChange{Removed: []models.Book{cleanCode, pragmatic}}},
+		{"changed", []models.Book{goBook, cleanCode}, []models.Book{goBook, discounted}, CatalogChange{Changed: []BookChange{{Before: cleanCode, After: discounted}}}},
+		{"repeated id keeps the last book", []models.Book{cleanCode}, []models.Book{cleanCode, discounted}, CatalogChange{Changed: []BookChange{{Before: cleanCode, After: discounted}}}},
+		{"everything", []models.Book{goBook, cleanCode}, []models.Book{discounted, pragmatic}, CatalogChange{
+			Added:   []models.Book{pragmatic},
+			Removed: []models.Book{goBook},
+			Changed: []BookChange{{Before: cleanCode, After: discounted}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := DiffCatalogs(tt.before, tt.after)
+
+			// Assert
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Empty(), got.Empty())
+		})
+	}
+}
+
+// countingRepository counts how many times it was queried.
+type countingRepository struct {
+	books []models.Book
+	err   error
+	calls int
+}
+
+func (r *countingRepository) GetBooksProvider(ctx context.Context) ([]models.Book, error) {
+	r.calls++
+	return r.books, r.err
+}
+
+// catalogStub is a BooksRepository whose answer tests change while a
+// CatalogRefresher polls it.
+type catalogStub struct {
+	mu    sync.Mutex
+	books []models.Book
+	err   error
+	calls int
+}
+
+func (s *catalogStub) GetBooksProvider(context.Context) ([]models.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return s.books, s.err
+}
+
+func (s *catalogStub) set(books []models.Book, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books, s.err = books, err
+}
+
+func TestCatalogRefresher_ServesSnapshot(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	refresher.now = func() time.Time { return now }
+
+	// Act
+	first, err1 := refresher.GetBooksProvider(context.Background())
+	second, err2 := refresher.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, []models.Book{{ID: 1}}, second)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, &CatalogSnapshot{Books: []models.Book{{ID: 1}}, Version: 1, ChangedAt: now, RefreshedAt: now, ISBNs: NewISBNIndex([]models.Book{{ID: 1}})}, refresher.Snapshot())
+	assert.Equal(t, now, refresher.LastRefresh())
+	assert.Equal(t, time.Minute, refresher.TTL())
+}
+
+func TestCatalogRefresher_ErrorBeforeFirstSnapshot(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{err: ErrServiceUnavailable}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+
+	// Act
+	_, err1 := refresher.GetBooksProvider(context.Background())
+	_, err2 := refresher.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, err1, ErrServiceUnavailable)
+	assert.ErrorIs(t, err2, ErrServiceUnavailable)
+	assert.Equal(t, 2, inner.calls)
+	assert.Nil(t, refresher.Snapshot())
+	assert.True(t, refresher.LastRefresh().IsZero())
+}
+
+func TestCatalogRefresher_KeepsSnapshotOnError(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	refresher.now = func() time.Time { return now }
+	_, _ = refresher.Refresh(context.Background())
+	succeededAt := now
+
+	// Act
+	now = now.Add(time.Minute)
+	inner.books, inner.err = nil, errors.New("boom")
+	_, refreshErr := refresher.Refresh(context.Background())
+	books, err := refresher.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.EqualError(t, refreshErr, "boom")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Book{{ID: 1}}, books)
+	assert.Equal(t, RefreshStatus{LastSuccess: succeededAt, LastAttempt: now, Err: refreshErr}, refresher.Status())
+}
+
+func TestCatalogRefresher_LastRefreshFollowsChanges(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, 30*time.Second)
+	changedAt := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	now := changedAt
+	refresher.now = func() time.Time { return now }
+	_, _ = refresher.Refresh(context.Background())
+
+	// Act
+	now = now.Add(30 * time.Second)
+	_, _ = refresher.Refresh(context.Background())
+	unchangedLastRefresh, unchangedTTL := refresher.LastRefresh(), refresher.TTL()
+	now = now.Add(30 * time.Second)
+	inner.books = []models.Book{{ID: 1, Price: 10}}
+	_, _ = refresher.Refresh(context.Background())
+
+	// Assert
+	assert.Equal(t, changedAt, unchangedLastRefresh)
+	assert.Equal(t, time.Minute, unchangedTTL)
+	assert.Equal(t, now, refresher.LastRefresh())
+	assert.Equal(t, 30*time.Second, refresher.TTL())
+	assert.Equal(t, now, refresher.Status().LastSuccess)
+}
+
+func TestCatalogRefresher_ISBNIndexPerVersion(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1, ISBN: "9780132350884"}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+
+	// Act
+	first, err := refresher.ISBNIndex(context.Background())
+	_, _ = refresher.Refresh(context.Background())
+	unchanged, _ := refresher.ISBNIndex(context.Background())
+	inner.books = []models.Book{{ID: 2, ISBN: "0-201-61622-X"}}
+	_, _ = refresher.Refresh(context.Background())
+	changed, _ := refresher.ISBNIndex(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Same(t, first, unchanged)
+	assert.NotSame(t, first, changed)
+	books, _ := changed.Lookup("9780201616224")
+	assert.Equal(t, []models.Book{{ID: 2, ISBN: "9780201616224"}}, books)
+}
+
+func TestCatalogRefresher_ISBNIndexBeforeFirstSnapshot(t *testing.T) {
+	// Arrange
+	refresher := NewCatalogRefresher(&countingRepository{err: ErrServiceUnavailable}, time.Minute)
+
+	// Act
+	index, err := refresher.ISBNIndex(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.Nil(t, index)
+}
+
+func TestCatalogRefresher_CancelledRefreshIsNotRecorded(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+	_, _ = refresher.Refresh(context.Background())
+	before := refresher.Status()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	inner.err = context.Canceled
+
+	// Act
+	_, err := refresher.Refresh(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, before, refresher.Status())
+	assert.NoError(t, refresher.Status().Err)
+}
+
+func TestCatalogRefresher_PublishesChanges(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1, Price: 40}, {ID: 2, Price: 50}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	refresher.now = func() time.Time { return now }
+	changes, cancel := refresher.Subscribe(4)
+	defer cancel()
+
+	// Act
+	_, _ = refresher.Refresh(context.Background())
+	now = now.Add(time.Minute)
+	unchanged, _ := refresher.Refresh(context.Background())
+	inner.books = []models.Book{{ID: 2, Price: 35}, {ID: 3, Price: 45}}
+	now = now.Add(time.Minute)
+	_, _ = refresher.Refresh(context.Background())
+
+	// Assert
+	assert.True(t, unchanged.Empty())
+	assert.Equal(t, CatalogChange{
+		Version: 1,
+		At:      time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC),
+		Added:   []models.Book{{ID: 1, Price: 40}, {ID: 2, Price: 50}},
+	}, <-changes)
+	assert.Equal(t, CatalogChange{
+		Version: 2,
+		At:      now,
+		Added:   []models.Book{{ID: 3, Price: 45}},
+		Removed: []models.Book{{ID: 1, Price: 40}},
+		Changed: []BookChange{{Before: models.Book{ID: 2, Price: 50}, After: models.Book{ID: 2, Price: 35}}},
+	}, <-changes)
+	assert.Empty(t, changes)
+	assert.Equal(t, uint64(2), refresher.Snapshot().Version)
+}
+
+func TestCatalogRefresher_DropsChangesForSlowSubscribers(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+	slow, cancelSlow := refresher.Subscribe(1)
+	defer cancelSlow()
+	cancelled, cancel := refresher.Subscribe(1)
+	cancel()
+
+	// Act
+	_, _ = refresher.Refresh(context.Background())
+	inner.books = []models.Book{{ID: 2}}
+	_, _ = refresher.Refresh(context.Background())
+
+	// Assert
+	assert.Equal(t, uint64(1), (<-slow).Version)
+	assert.Empty(t, slow)
+	_, open := <-cancelled
+	assert.False(t, open)
+	assert.Equal(t, uint64(2), refresher.Snapshot().Version)
+}
+
+func TestCatalogRefresher_RunStopsWithContext(t *testing.T) {
+	// Arrange
+	inner := &catalogStub{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, time.Millisecond)
+	changes, cancelSubscription := refresher.Subscribe(16)
+	defer cancelSubscription()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	// Act
+	go func() {
+		refresher.Run(ctx)
+		close(stopped)
+	}()
+	first := <-changes
+	inner.set([]models.Book{{ID: 1}, {ID: 2}}, nil)
+	second := <-changes
+	cancel()
+	<-stopped
+	late, _ := refresher.Subscribe(1)
+
+	// Assert
+	assert.Equal(t, []models.Book{{ID: 1}}, first.Added)
+	assert.Equal(t, []models.Book{{ID: 2}}, second.Added)
+	_, open := <-changes
+	assert.False(t, open)
+	_, open = <-late
+	assert.False(t, open)
+}
+
+func TestCatalogRefresher_ConcurrentReadsDuringRefresh(t *testing.T) {
+	// Arrange
+	inner := &catalogStub{books: []models.Book{{ID: 1}}}
+	refresher := NewCatalogRefresher(inner, time.Minute)
+	_, _ = refresher.Refresh(context.Background())
+
+	// Act
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			books, err := refresher.GetBooksProvider(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, books, 1)
+		}()
+		go func() {
+			defer wg.Done()
+			inner.set([]models.Book{{ID: uint(i + 2)}}, nil)
+			_, err := refresher.Refresh(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	inner.mu.Lock()
+	defer inner.mu.Unlock()
+	assert.Equal(t, 11, inner.calls)
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ProxyDirect as TransportOptions.Proxy ignores HTTP_PROXY and HTTPS_PROXY.
+const ProxyDirect = "direct"
+
+const (
+	defaultMaxIdleConns    = 16
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportOptions tune the connections to the upstream. The zero value
+// keeps a pool of 16 idle connections and honours the proxy environment.
+type TransportOptions struct {
+	// MaxIdleConns is the number of idle connections kept open to the
+	// upstream host.
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for mTLS.
+	CertFile string
+	KeyFile  string
+	// Proxy is a proxy URL, ProxyDirect, or empty for HTTP_PROXY and
+	// HTTPS_PROXY.
+	Proxy string
+}
+
+// defaultTransport is shared by every repository built without a transport,
+// so they all draw on one connection pool.
+var defaultTransport = newBaseTransport(TransportOptions{})
+
+// NewTransport builds the transport ExternalBooksRepository uses by default
+// with opts applied. Reading CAFile, CertFile or KeyFile may fail.
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	transport := newBaseTransport(opts)
+
+	switch opts.Proxy {
+	case "":
+	case ProxyDirect:
+		transport.Proxy = nil
+	default:
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s: no PEM certificates found", opts.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = roots
+	}
+
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	return transport, nil
+}
+
+func newBaseTransport(opts TransportOptions) *http.Transport {
+	maxIdle := opts.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	idleTimeout := opts.IdleConnTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultIdleConnTimeout
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   maxIdle,
+		IdleConnTimeout:       idleTimeout,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+}
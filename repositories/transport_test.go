@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// writePEM writes blocks of typ to a temporary file named name and returns
+// its path.
+func writePEM(t *testing.T, name, typ string, blocks ...[]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: block})...)
+	}
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// clientCertificate creates a self-signed client certificate and returns
+// it with the paths of its PEM certificate and key.
+func clientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "bookshop"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return cert, writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func newTLSBooksServer(t *testing.T) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]models.Book{})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewTransport_CAFile(t *testing.T) {
+	// Arrange
+	server := newTLSBooksServer(t)
+	server.StartTLS()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	trusted, err := NewTransport(TransportOptions{CAFile: caFile})
+	assert.NoError(t, err)
+
+	// Act
+	_, trustedErr := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Transport: trusted}).GetBooksProvider(context.Background())
+	_, untrustedErr := NewExternalBooksRepository(server.URL).GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, trustedErr)
+	assert.ErrorIs(t, untrustedErr, ErrServiceUnavailable)
+	assert.ErrorContains(t, untrustedErr, "certificate")
+}
+
+func TestNewTransport_ClientCertificate(t *testing.T) {
+	// Arrange
+	cert, certFile, keyFile := clientCertificate(t)
+	server := newTLSBooksServer(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	withCert, err := NewTransport(TransportOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	assert.NoError(t, err)
+	withoutCert, err := NewTransport(TransportOptions{CAFile: caFile})
+	assert.NoError(t, err)
+
+	// Act
+	_, withErr := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Transport: withCert}).GetBooksProvider(context.Background())
+	_, withoutErr := NewExternalBooksRepositoryWithOptions(server.URL, ExternalBooksOptions{Transport: withoutCert}).GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, withErr)
+	assert.ErrorIs(t, withoutErr, ErrServiceUnavailable)
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	// Arrange
+	req, _ := http.NewRequest(http.MethodGet, "http://upstream.example/books", nil)
+
+	// Act
+	viaProxy, proxyErr := NewTransport(TransportOptions{Proxy: "http://proxy.internal:3128"})
+	direct, directErr := NewTransport(TransportOptions{Proxy: ProxyDirect})
+
+	// Assert
+	assert.NoError(t, proxyErr)
+	proxy, err := viaProxy.Proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy.internal:3128", proxy.String())
+	assert.NoError(t, directErr)
+	assert.Nil(t, direct.Proxy)
+}
+
+func TestNewTransport_Defaults(t *testing.T) {
+	// Act
+	transport, err := NewTransport(TransportOptions{MaxIdleConns: 4})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, defaultIdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+}
+
+func TestNewTransport_Errors(t *testing.T) {
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0o600)
+	_, certFile, _ := clientCertificate(t)
+
+	tests := []struct {
+		name string
+		opts TransportOptions
+		want string
+	}{
+		{"invalid proxy", TransportOptions{Proxy: "proxy.internal"}, `invalid proxy URL "proxy.internal"`},
+		{"missing CA bundle", TransportOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, "no such file"},
+		{"empty CA bundle", TransportOptions{CAFile: notPEM}, "no PEM certificates found"},
+		{"certificate without key", TransportOptions{CertFile: certFile}, "must be set together"},
+		{"key that does not match", TransportOptions{CertFile: certFile, KeyFile: notPEM}, "client certificate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			transport, err := NewTransport(tt.opts)
+
+			// Assert
+			assert.ErrorContains(t, err, tt.want)
+			assert.Nil(t, transport)
+		})
+	}
+}
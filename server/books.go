@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/repositories"
+)
+
+// NewBooksRepository opens the catalog the API serves: cfg.File, reloaded
+// as it changes, or else a snapshot of booksURL refreshed in the background.
+// The polling stops when ctx is done and wait returns once it has. A
+// catalog that cannot be fetched at startup is logged and fetched again on
+// the first request.
+func NewBooksRepository(ctx context.Context, cfg config.BooksConfig, booksURL string) (repo repositories.BooksRepository, wait func(), err error) {
+	done := make(chan struct{})
+	wait = func() { <-done }
+
+	if cfg.File != "" {
+		fileRepo, err := repositories.NewFileBooksRepository(cfg.File, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("books file: %w", err)
+		}
+		go func() {
+			defer close(done)
+			fileRepo.Watch(ctx, cfg.PollInterval)
+		}()
+		return fileRepo, wait, nil
+	}
+
+	upstreamRepo, err := NewUpstreamRepository(booksURL, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("upstream: %w", err)
+	}
+	refresher := repositories.NewCatalogRefresher(upstreamRepo, cfg.RefreshInterval)
+	if _, err := refresher.Refresh(ctx); err != nil {
+		slog.Warn("catalog not loaded at startup", slog.String("error", err.Error()))
+	}
+	go func() {
+		defer close(done)
+		refresher.Run(ctx)
+	}()
+	return refresher, wait, nil
+}
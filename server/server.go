@@ -0,0 +1,189 @@
+package server
+
+import (
+	"html/template"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"educabot.com/bookshop/auth"
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/handlers"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/services"
+	"educabot.com/bookshop/static"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	legacyDeprecatedSince = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	legacySunset          = time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// NewRouter registers every route and middleware of the API on top of
+// booksRepo. When booksRepo reports its cache age, responses get validators
+// tied to it.
+func NewRouter(cfg config.Config, booksRepo repositories.BooksRepository) *gin.Engine {
+	router := gin.Default()
+	router.SetTrustedProxies(nil)
+	router.Use(handlers.RequestID(), handlers.Compression(handlers.DefaultCompressionOptions()), handlers.Problems())
+	router.Use(newCORS(cfg.CORS))
+
+	// Autenticación
+	authenticator := newAuthenticator(cfg.Auth)
+	router.Use(handlers.Identify(authenticator))
+	if cfg.RateLimit.Rate > 0 {
+		router.Use(newRateLimiter(cfg.RateLimit))
+	}
+	cacheAge, _ := booksRepo.(handlers.CacheAge)
+	router.Use(handlers.ConditionalGET(cacheAge, nil))
+
+	// Servicio con lógica
+	service := services.NewMetricsService(booksRepo)
+
+	booksService := services.NewBooksService(booksRepo)
+	searchService := services.NewSearchService(booksRepo)
+	suggestService := services.NewSuggestService(booksRepo)
+	recommender := newRecommender(booksRepo, cfg.Recommender)
+	reportsService := services.NewReportsService(booksRepo)
+	exportService := services.NewExportService(booksRepo)
+
+	// Handler con dependencias
+	handler := handlers.NewHandler(service)
+	booksHandler := handlers.NewBooksHandler(booksService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	suggestHandler := handlers.NewSuggestHandler(suggestService)
+	recommendationsHandler := handlers.NewRecommendationsHandler(recommender)
+	reportsHandler := handlers.NewReportsHandler(reportsService, newReportTemplates(cfg.Reports))
+	exportHandler := handlers.NewExportHandler(exportService)
+
+	requireMetricsRead := handlers.RequireScopes(authenticator, auth.ScopeMetricsRead)
+
+	registerCatalogRoutes := func(routes gin.IRoutes) {
+		routes.GET("/metrics/distribution", requireMetricsRead, handler.GetDistribution)
+		routes.GET("/books/isbn/duplicates", booksHandler.GetDuplicateISBNs)
+		routes.GET("/books/isbn/:isbn", booksHandler.GetBookByISBN)
+		routes.GET("/books/:id/similar", recommendationsHandler.GetSimilarBooks)
+		routes.GET("/search", searchHandler.Search)
+		routes.GET("/suggest", suggestHandler.Suggest)
+		routes.GET("/reports/summary", requireMetricsRead, reportsHandler.GetSummary)
+		routes.GET("/export/books", requireMetricsRead, exportHandler.GetBooks)
+		routes.GET("/export/authors", requireMetricsRead, exportHandler.GetAuthors)
+	}
+
+	// Documentación
+	router.GET("/openapi.json", handlers.GetOpenAPISpec)
+	router.StaticFileFS("/docs", "docs.html", http.FS(static.Docs))
+
+	// Dashboard
+	ui, err := handlers.NewUI(static.Dashboard, "/ui")
+	if err != nil {
+		log.Fatalf("dashboard: %v", err)
+	}
+	router.GET("/ui", ui.GetIndex)
+	router.GET("/ui/assets/:name", ui.GetAsset)
+
+	// Rutas sin versión (legacy)
+	router.GET("/", handlers.Deprecated(legacyDeprecatedSince, legacySunset, "/v1/metrics"), handler.GetMetricsNegotiated)
+	registerCatalogRoutes(router)
+
+	// v1: contrato congelado
+	v1 := router.Group("/v1")
+	v1.GET("/metrics", handler.GetMetricsV1)
+	registerCatalogRoutes(v1)
+
+	// v2: respuestas tipadas
+	v2 := router.Group("/v2")
+	v2.GET("/metrics", handler.GetMetricsV2)
+	registerCatalogRoutes(v2)
+
+	return router
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) gin.HandlerFunc {
+	options := handlers.RateLimitOptions{
+		Limit: handlers.RateLimit{Rate: cfg.Rate, Burst: cfg.Burst},
+		Key:   handlers.KeyByIP,
+		Store: handlers.NewMemoryBucketStore(cfg.IdleTTL),
+	}
+	switch cfg.Key {
+	case config.RateLimitByAPIKey:
+		options.Key = handlers.KeyByPrincipal
+	case config.RateLimitByRoute:
+		options.Key = handlers.KeyByRoute
+	}
+	return handlers.RateLimiter(options)
+}
+
+func newCORS(cfg config.CORSConfig) gin.HandlerFunc {
+	return handlers.CORS(handlers.CORSOptions{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   handlers.DefaultExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+}
+
+// newReportTemplates falls back to the embedded templates when the
+// configured directory cannot be used.
+func newReportTemplates(cfg config.ReportsConfig) *template.Template {
+	templates, err := handlers.LoadReportTemplates(cfg.TemplatesDir)
+	if err != nil {
+		slog.Warn("report templates not loaded", slog.String("dir", cfg.TemplatesDir), slog.String("error", err.Error()))
+		templates, err = handlers.LoadReportTemplates("")
+		if err != nil {
+			log.Fatalf("embedded report templates: %v", err)
+		}
+	}
+	return templates
+}
+
+// newAuthenticator loads API keys and JWT verification keys. Sources that
+// are not configured or fail to load are left out, so their credentials are
+// rejected rather than accepted.
+func newAuthenticator(cfg config.AuthConfig) *auth.Authenticator {
+	authenticator := &auth.Authenticator{}
+	if cfg.APIKeysFile != "" {
+		if file, err := os.Open(cfg.APIKeysFile); err != nil {
+			slog.Warn("api keys not loaded", slog.String("error", err.Error()))
+		} else {
+			authenticator.APIKeys, err = auth.LoadAPIKeys(file)
+			file.Close()
+			if err != nil {
+				slog.Warn("api keys not loaded", slog.String("error", err.Error()))
+			}
+		}
+	}
+	if cfg.JWKSFile != "" {
+		if file, err := os.Open(cfg.JWKSFile); err != nil {
+			slog.Warn("jwks not loaded", slog.String("error", err.Error()))
+		} else {
+			keys, err := auth.LoadJWKS(file)
+			file.Close()
+			if err != nil {
+				slog.Warn("jwks not loaded", slog.String("error", err.Error()))
+			} else {
+				authenticator.JWT = &auth.JWTVerifier{
+					Keys:     keys,
+					Issuer:   cfg.JWTIssuer,
+					Audience: cfg.JWTAudience,
+					Leeway:   30 * time.Second,
+				}
+			}
+		}
+	}
+	return authenticator
+}
+
+// newRecommender applies the configured weights and co-purchase dataset.
+func newRecommender(repository repositories.BooksRepository, cfg config.RecommenderConfig) *services.Recommender {
+	options := services.DefaultRecommenderOptions()
+	options.Weights = cfg.Weights
+	recommender := services.NewRecommender(repository, options)
+	recommender.SetCoPurchases(cfg.CoPurchases)
+	return recommender
+}
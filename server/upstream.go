@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+
+	"educabot.com/bookshop/config"
+	"educabot.com/bookshop/repositories"
+)
+
+// NewUpstreamRepository reaches booksURL with cfg.Upstream applied, its
+// credentials included, through cfg.Cassette when it names a file.
+func NewUpstreamRepository(booksURL string, cfg config.BooksConfig) (*repositories.ExternalBooksRepository, error) {
+	transport, err := repositories.NewTransport(repositories.TransportOptions{
+		MaxIdleConns: cfg.Upstream.MaxIdleConns,
+		CAFile:       cfg.Upstream.CAFile,
+		CertFile:     cfg.Upstream.CertFile,
+		KeyFile:      cfg.Upstream.KeyFile,
+		Proxy:        cfg.Upstream.Proxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if cfg.Cassette.File != "" {
+		roundTripper, err = repositories.NewCassetteTransport(cfg.Cassette.File, repositories.CassetteMode(cfg.Cassette.Mode), repositories.CassetteOptions{
+			Match:         cfg.Cassette.Match,
+			RedactHeaders: []string{cfg.Upstream.Auth.APIKeyHeader},
+			Transport:     transport,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return repositories.NewExternalBooksRepositoryWithOptions(booksURL, repositories.ExternalBooksOptions{
+		Transport:   roundTripper,
+		Timeout:     cfg.Upstream.Timeout,
+		UserAgent:   cfg.Upstream.UserAgent,
+		Credentials: newCredentials(cfg.Upstream, transport),
+	}), nil
+}
+
+// newCredentials returns nil when cfg asks for no authentication. Token
+// requests share transport but are never recorded to a cassette.
+func newCredentials(cfg config.UpstreamConfig, transport http.RoundTripper) repositories.Credentials {
+	auth := cfg.Auth
+	switch auth.Type {
+	case "bearer":
+		return repositories.BearerToken(string(auth.Token))
+	case "basic":
+		return repositories.BasicAuth(auth.Username, string(auth.Password))
+	case "api_key":
+		return repositories.HeaderAPIKey(auth.APIKeyHeader, string(auth.APIKey))
+	case "oauth2":
+		return repositories.NewClientCredentials(repositories.ClientCredentialsConfig{
+			TokenURL:     auth.TokenURL,
+			ClientID:     auth.ClientID,
+			ClientSecret: string(auth.ClientSecret),
+			Scopes:       auth.Scopes,
+			Client:       &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		})
+	default:
+		return nil
+	}
+}
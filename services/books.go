@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+var ErrInvalidISBN = errors.New("invalid isbn")
+
+type ISBNLookupResult struct {
+	Book       models.Book   `json:"book"`
+	Duplicates []models.Book `json:"duplicates,omitempty"`
+}
+
+type BooksService struct {
+	booksRepositories repositories.BooksRepository
+}
+
+func NewBooksService(repository repositories.BooksRepository) *BooksService {
+	return &BooksService{booksRepositories: repository}
+}
+
+func (s *BooksService) GetBookByISBN(ctx context.Context, isbn string) (*ISBNLookupResult, error) {
+	if _, err := models.NormalizeISBN(isbn); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidISBN, err)
+	}
+
+	index, err := s.isbnIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := index.Lookup(isbn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidISBN, err)
+	}
+	if len(matches) == 0 {
+		return nil, ErrBookNotFound
+	}
+
+	return &ISBNLookupResult{Book: matches[0], Duplicates: matches[1:]}, nil
+}
+
+func (s *BooksService) DuplicateISBNs(ctx context.Context) ([]repositories.ISBNDuplicate, error) {
+	index, err := s.isbnIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.Duplicates(), nil
+}
+
+// isbnIndex uses the repository's index when it keeps one per catalog and
+// otherwise indexes the catalog for this request.
+func (s *BooksService) isbnIndex(ctx context.Context) (*repositories.ISBNIndex, error) {
+	if indexer, ok := s.booksRepositories.(repositories.ISBNIndexer); ok {
+		index, err := indexer.ISBNIndex(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+		}
+		return index, nil
+	}
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+	return repositories.NewISBNIndex(books), nil
+}
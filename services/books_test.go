@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBooksService_GetBookByISBN_Success(t *testing.T) {
+	// Arrange
+	service := NewBooksService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	result, err := service.GetBookByISBN(context.Background(), "0132350882")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Clean Code", result.Book.Name)
+	assert.Equal(t, "9780132350884", result.Book.ISBN)
+	assert.Empty(t, result.Duplicates)
+}
+
+func TestBooksService_GetBookByISBN_InvalidISBN(t *testing.T) {
+	// Arrange
+	service := NewBooksService(&MockBooksRepositoryWithError{})
+
+	// Act
+	result, err := service.GetBookByISBN(context.Background(), "9780132350885")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidISBN)
+	assert.ErrorIs(t, err, models.ErrISBNChecksum)
+	assert.Nil(t, result)
+}
+
+func TestBooksService_GetBookByISBN_NotFound(t *testing.T) {
+	// Arrange
+	service := NewBooksService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	result, err := service.GetBookByISBN(context.Background(), "9781491950357")
+
+	// Assert
+	assert.Equal(t, ErrBookNotFound, err)
+	assert.Nil(t, result)
+}
+
+func TestBooksService_GetBookByISBN_RepositoryError(t *testing.T) {
+	// Arrange
+	service := NewBooksService(&MockBooksRepositoryWithError{})
+
+	// Act
+	result, err := service.GetBookByISBN(context.Background(), "9780132350884")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	assert.Nil(t, result)
+}
+
+func TestBooksService_DuplicateISBNs(t *testing.T) {
+	// Arrange
+	service := NewBooksService(&testutil.StaticBooksRepository{Books: []models.Book{
+		{ID: 1, Name: "First", ISBN: "9780132350884"},
+		{ID: 2, Name: "Second", ISBN: "0132350882"},
+	}})
+
+	// Act
+	duplicates, err := service.DuplicateISBNs(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 1)
+	assert.Equal(t, "9780132350884", duplicates[0].ISBN)
+}
+
+func TestBooksService_UsesRepositoryISBNIndex(t *testing.T) {
+	// Arrange
+	index := repositories.NewISBNIndex([]models.Book{
+		{ID: 1, Name: "First", ISBN: "9780132350884"},
+		{ID: 2, Name: "Second", ISBN: "0132350882"},
+	})
+	service := NewBooksService(&indexedBooksRepository{index: index})
+
+	// Act
+	result, err := service.GetBookByISBN(context.Background(), "9780132350884")
+	duplicates, duplicatesErr := service.DuplicateISBNs(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "First", result.Book.Name)
+	assert.NoError(t, duplicatesErr)
+	assert.Len(t, duplicates, 1)
+}
+
+func TestBooksService_RepositoryISBNIndexError(t *testing.T) {
+	// Arrange
+	service := NewBooksService(&indexedBooksRepository{err: repositories.ErrServiceUnavailable})
+
+	// Act
+	_, err := service.GetBookByISBN(context.Background(), "9780132350884")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	assert.ErrorIs(t, err, repositories.ErrServiceUnavailable)
+}
+
+// indexedBooksRepository keeps an ISBN index, as CatalogRefresher does.
+type indexedBooksRepository struct {
+	index *repositories.ISBNIndex
+	err   error
+}
+
+func (r *indexedBooksRepository) GetBooksProvider(context.Context) ([]models.Book, error) {
+	panic("the ISBN index should be used instead of the catalog")
+}
+
+func (r *indexedBooksRepository) ISBNIndex(context.Context) (*repositories.ISBNIndex, error) {
+	return r.index, r.err
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"educabot.com/bookshop/models"
+)
+
+var ErrInvalidDistribution = errors.New("invalid distribution request")
+
+const (
+	defaultDistributionBuckets = 10
+	maxDistributionBuckets     = 100
+)
+
+type DistributionField string
+
+const (
+	DistributionPrice     DistributionField = "price"
+	DistributionUnitsSold DistributionField = "units_sold"
+)
+
+type BucketMode string
+
+const (
+	BucketsFixed    BucketMode = "fixed"
+	BucketsQuantile BucketMode = "quantile"
+	BucketsCustom   BucketMode = "custom"
+)
+
+type DistributionOptions struct {
+	Field   DistributionField
+	Mode    BucketMode
+	Buckets int
+	// Edges are the bucket boundaries for BucketsCustom, strictly increasing.
+	Edges []float64
+}
+
+// HistogramBucket counts values in [Lower, Upper). The last bucket also
+// includes Upper.
+type HistogramBucket struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+}
+
+type DistributionResult struct {
+	Field      DistributionField `json:"field"`
+	Mode       BucketMode        `json:"mode"`
+	Count      int               `json:"count"`
+	Min        float64           `json:"min"`
+	Max        float64           `json:"max"`
+	Mean       float64           `json:"mean"`
+	Median     float64           `json:"median"`
+	StdDev     float64           `json:"std_dev"`
+	Skewness   float64           `json:"skewness"`
+	Q1         float64           `json:"q1"`
+	Q3         float64           `json:"q3"`
+	IQR        float64           `json:"iqr"`
+	LowerFence float64           `json:"lower_fence"`
+	UpperFence float64           `json:"upper_fence"`
+	Buckets    []HistogramBucket `json:"buckets"`
+	OutOfRange int               `json:"out_of_range"`
+	Outliers   []models.Book     `json:"outliers"`
+}
+
+func (s *MetricsService) ComputeDistribution(ctx context.Context, options DistributionOptions) (*DistributionResult, error) {
+	value, err := distributionValue(options.Field)
+	if err != nil {
+		return nil, err
+	}
+	if options.Mode == "" {
+		options.Mode = BucketsFixed
+	}
+	if options.Buckets == 0 {
+		options.Buckets = defaultDistributionBuckets
+	}
+	if err := validateDistributionOptions(options); err != nil {
+		return nil, err
+	}
+
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+
+	return computeDistribution(books, value, options), nil
+}
+
+func distributionValue(field DistributionField) (func(models.Book) float64, error) {
+	switch field {
+	case DistributionPrice:
+		return func(b models.Book) float64 { return float64(b.Price) }, nil
+	case DistributionUnitsSold:
+		return func(b models.Book) float64 { return float64(b.UnitsSold) }, nil
+	default:
+		return nil, fmt.Errorf("%w: field must be price or units_sold", ErrInvalidDistribution)
+	}
+}
+
+func validateDistributionOptions(options DistributionOptions) error {
+	switch options.Mode {
+	case BucketsFixed, BucketsQuantile:
+		if options.Buckets < 1 || options.Buckets > maxDistributionBuckets {
+			return fmt.Errorf("%w: buckets must be between 1 and %d", ErrInvalidDistribution, maxDistributionBuckets)
+		}
+		if len(options.Edges) > 0 {
+			return fmt.Errorf("%w: edges only apply to custom mode", ErrInvalidDistribution)
+		}
+	case BucketsCustom:
+		if len(options.Edges) < 2 {
+			return fmt.Errorf("%w: custom buckets need at least two edges", ErrInvalidDistribution)
+		}
+		if len(options.Edges) > maxDistributionBuckets+1 {
+			return fmt.Errorf("%w: at most %d custom edges", ErrInvalidDistribution, maxDistributionBuckets+1)
+		}
+		for i := 1; i < len(options.Edges); i++ {
+			if !(options.Edges[i] > options.Edges[i-1]) {
+				return fmt.Errorf("%w: custom edges must be strictly increasing", ErrInvalidDistribution)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: mode must be fixed, quantile or custom", ErrInvalidDistribution)
+	}
+	return nil
+}
+
+func computeDistribution(books []models.Book, value func(models.Book) float64, options DistributionOptions) *DistributionResult {
+	result := &DistributionResult{
+		Field:    options.Field,
+		Mode:     options.Mode,
+		Count:    len(books),
+		Buckets:  []HistogramBucket{},
+		Outliers: []models.Book{},
+	}
+	if len(books) == 0 {
+		return result
+	}
+
+	values := make([]float64, len(books))
+	for i, book := range books {
+		values[i] = value(book)
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := float64(len(sorted))
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / n
+	var m2, m3 float64
+	for _, v := range sorted {
+		d := v - mean
+		m2 += d * d
+		m3 += d * d * d
+	}
+	m2 /= n
+	m3 /= n
+
+	result.Min = sorted[0]
+	result.Max = sorted[len(sorted)-1]
+	result.Mean = mean
+	result.Median = quantile(sorted, 0.5)
+	result.StdDev = math.Sqrt(m2)
+	if m2 > 0 {
+		result.Skewness = m3 / math.Pow(m2, 1.5)
+	}
+	result.Q1 = quantile(sorted, 0.25)
+	result.Q3 = quantile(sorted, 0.75)
+	result.IQR = result.Q3 - result.Q1
+	result.LowerFence = result.Q1 - 1.5*result.IQR
+	result.UpperFence = result.Q3 + 1.5*result.IQR
+
+	for i, v := range values {
+		if v < result.LowerFence || v > result.UpperFence {
+			result.Outliers = append(result.Outliers, books[i])
+		}
+	}
+
+	edges := bucketEdges(sorted, options)
+	for i := 1; i < len(edges); i++ {
+		result.Buckets = append(result.Buckets, HistogramBucket{Lower: edges[i-1], Upper: edges[i]})
+	}
+	for _, v := range sorted {
+		// Index of the first edge above v, so bucket i covers [edges[i], edges[i+1]).
+		i := sort.Search(len(edges), func(j int) bool { return edges[j] > v }) - 1
+		if i == len(edges)-1 && v == edges[i] {
+			i--
+		}
+		if i < 0 || i >= len(result.Buckets) {
+			result.OutOfRange++
+			continue
+		}
+		result.Buckets[i].Count++
+	}
+	return result
+}
+
+func bucketEdges(sorted []float64, options DistributionOptions) []float64 {
+	switch options.Mode {
+	case BucketsCustom:
+		return options.Edges
+	case BucketsQuantile:
+		edges := []float64{sorted[0]}
+		for i := 1; i <= options.Buckets; i++ {
+			edge := quantile(sorted, float64(i)/float64(options.Buckets))
+			if edge > edges[len(edges)-1] {
+				edges = append(edges, edge)
+			}
+		}
+		if len(edges) == 1 {
+			edges = append(edges, edges[0])
+		}
+		return edges
+	default:
+		lower, upper := sorted[0], sorted[len(sorted)-1]
+		if lower == upper {
+			return []float64{lower, upper}
+		}
+		width := (upper - lower) / float64(options.Buckets)
+		edges := make([]float64, options.Buckets+1)
+		for i := range edges {
+			edges[i] = lower + width*float64(i)
+		}
+		edges[options.Buckets] = upper
+		return edges
+	}
+}
+
+// quantile interpolates linearly between the closest ranks of sorted.
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
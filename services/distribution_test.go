@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func distributionCatalog() *testutil.StaticBooksRepository {
+	prices := []uint{10, 12, 14, 16, 18, 20, 22, 24, 100}
+	books := make([]models.Book, len(prices))
+	for i, price := range prices {
+		books[i] = models.Book{ID: uint(i + 1), Name: "Book", Price: price, UnitsSold: uint(i+1) * 100}
+	}
+	return &testutil.StaticBooksRepository{Books: books}
+}
+
+func TestMetricsService_ComputeDistribution_FixedBuckets(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(distributionCatalog())
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{Field: DistributionPrice, Buckets: 3})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, BucketsFixed, result.Mode)
+	assert.Equal(t, 9, result.Count)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 10, Upper: 40, Count: 8},
+		{Lower: 40, Upper: 70, Count: 0},
+		{Lower: 70, Upper: 100, Count: 1},
+	}, result.Buckets)
+	assert.Equal(t, 0, result.OutOfRange)
+}
+
+func TestMetricsService_ComputeDistribution_Statistics(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(distributionCatalog())
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{Field: DistributionPrice})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, float64(10), result.Min)
+	assert.Equal(t, float64(100), result.Max)
+	assert.InDelta(t, 26.2222, result.Mean, 1e-3)
+	assert.Equal(t, float64(18), result.Median)
+	assert.InDelta(t, 26.4398, result.StdDev, 1e-3)
+	assert.Greater(t, result.Skewness, 2.0)
+	assert.Equal(t, float64(14), result.Q1)
+	assert.Equal(t, float64(22), result.Q3)
+	assert.Equal(t, float64(8), result.IQR)
+	assert.Equal(t, float64(2), result.LowerFence)
+	assert.Equal(t, float64(34), result.UpperFence)
+	assert.Len(t, result.Outliers, 1)
+	assert.Equal(t, uint(100), result.Outliers[0].Price)
+	assert.Len(t, result.Buckets, 10)
+}
+
+func TestMetricsService_ComputeDistribution_QuantileBuckets(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(distributionCatalog())
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{
+		Field: DistributionUnitsSold, Mode: BucketsQuantile, Buckets: 4,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 100, Upper: 300, Count: 2},
+		{Lower: 300, Upper: 500, Count: 2},
+		{Lower: 500, Upper: 700, Count: 2},
+		{Lower: 700, Upper: 900, Count: 3},
+	}, result.Buckets)
+	assert.Empty(t, result.Outliers)
+}
+
+func TestMetricsService_ComputeDistribution_CustomEdges(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(distributionCatalog())
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{
+		Field: DistributionPrice, Mode: BucketsCustom, Edges: []float64{12, 20, 50},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []HistogramBucket{
+		{Lower: 12, Upper: 20, Count: 4},
+		{Lower: 20, Upper: 50, Count: 3},
+	}, result.Buckets)
+	assert.Equal(t, 2, result.OutOfRange)
+}
+
+func TestMetricsService_ComputeDistribution_SingleValue(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(&testutil.StaticBooksRepository{Books: []models.Book{{Price: 5}, {Price: 5}}})
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{Field: DistributionPrice})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []HistogramBucket{{Lower: 5, Upper: 5, Count: 2}}, result.Buckets)
+	assert.Equal(t, float64(0), result.StdDev)
+	assert.Equal(t, float64(0), result.Skewness)
+}
+
+func TestMetricsService_ComputeDistribution_EmptyCatalog(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(&testutil.StaticBooksRepository{})
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{Field: DistributionPrice})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Count)
+	assert.Empty(t, result.Buckets)
+}
+
+func TestMetricsService_ComputeDistribution_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options DistributionOptions
+	}{
+		{"unknown field", DistributionOptions{Field: "isbn"}},
+		{"unknown mode", DistributionOptions{Field: DistributionPrice, Mode: "log"}},
+		{"too many buckets", DistributionOptions{Field: DistributionPrice, Buckets: 1000}},
+		{"negative buckets", DistributionOptions{Field: DistributionPrice, Buckets: -1}},
+		{"one custom edge", DistributionOptions{Field: DistributionPrice, Mode: BucketsCustom, Edges: []float64{1}}},
+		{"unsorted edges", DistributionOptions{Field: DistributionPrice, Mode: BucketsCustom, Edges: []float64{5, 1}}},
+		{"edges in fixed mode", DistributionOptions{Field: DistributionPrice, Mode: BucketsFixed, Buckets: 10, Edges: []float64{1, 5}}},
+		{"edges in quantile mode", DistributionOptions{Field: DistributionPrice, Mode: BucketsQuantile, Buckets: 4, Edges: []float64{1, 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewMetricsService(&MockBooksRepositoryWithError{})
+
+			// Act
+			result, err := service.ComputeDistribution(context.Background(), tt.options)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrInvalidDistribution)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestMetricsService_ComputeDistribution_RepositoryError(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(&MockBooksRepositoryWithError{})
+
+	// Act
+	result, err := service.ComputeDistribution(context.Background(), DistributionOptions{Field: DistributionPrice})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	assert.Nil(t, result)
+}
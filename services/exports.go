@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"educabot.com/bookshop/repositories"
+)
+
+type ExportColumn struct {
+	// Key names the field in NDJSON.
+	Key string
+	// Title is the CSV and spreadsheet header.
+	Title string
+}
+
+// ExportTable is a tabular view of catalog data. Each row holds one value per
+// column: string, uint or float64.
+type ExportTable struct {
+	Name    string
+	Columns []ExportColumn
+	// Rows calls yield once per row and stops at the first error.
+	Rows func(yield func(row []any) error) error
+}
+
+type ExportService struct {
+	booksRepositories repositories.BooksRepository
+}
+
+func NewExportService(repository repositories.BooksRepository) *ExportService {
+	return &ExportService{booksRepositories: repository}
+}
+
+// Books lists the catalog ordered by ID. The catalog is read before
+// returning, so upstream failures surface before any row is written.
+func (s *ExportService) Books(ctx context.Context) (*ExportTable, error) {
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+	books = slices.Clone(books)
+	slices.SortFunc(books, compareByIdentity)
+
+	return &ExportTable{
+		Name: "books",
+		Columns: []ExportColumn{
+			{Key: "id", Title: "ID"},
+			{Key: "name", Title: "Title"},
+			{Key: "author", Title: "Author"},
+			{Key: "isbn", Title: "ISBN"},
+			{Key: "units_sold", Title: "Units sold"},
+			{Key: "price", Title: "Price"},
+		},
+		Rows: func(yield func(row []any) error) error {
+			for _, book := range books {
+				if err := yield([]any{book.ID, book.Name, book.Author, book.ISBN, book.UnitsSold, book.Price}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+// Authors lists the per-author aggregates from SummarizeAuthors.
+func (s *ExportService) Authors(ctx context.Context) (*ExportTable, error) {
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+	summaries := SummarizeAuthors(books)
+
+	return &ExportTable{
+		Name: "authors",
+		Columns: []ExportColumn{
+			{Key: "author", Title: "Author"},
+			{Key: "books", Title: "Books"},
+			{Key: "units_sold", Title: "Units sold"},
+			{Key: "min_price", Title: "Lowest price"},
+			{Key: "max_price", Title: "Highest price"},
+			{Key: "mean_price", Title: "Mean price"},
+		},
+		Rows: func(yield func(row []any) error) error {
+			for _, summary := range summaries {
+				row := []any{summary.Author, summary.Books, summary.UnitsSold, summary.MinPrice, summary.MaxPrice, summary.MeanPrice}
+				if err := yield(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
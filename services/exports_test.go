@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectRows(t *testing.T, table *ExportTable) [][]any {
+	t.Helper()
+	var rows [][]any
+	assert.NoError(t, table.Rows(func(row []any) error {
+		rows = append(rows, row)
+		return nil
+	}))
+	return rows
+}
+
+func TestExportService_Books(t *testing.T) {
+	// Arrange
+	service := NewExportService(&testutil.StaticBooksRepository{Books: []models.Book{
+		{ID: 3, Name: "Third", Author: "C", UnitsSold: 1, Price: 30},
+		{ID: 1, Name: "First", Author: "A", UnitsSold: 2, Price: 10, ISBN: "9780134190440"},
+	}})
+
+	// Act
+	table, err := service.Books(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "books", table.Name)
+	assert.Len(t, table.Columns, 6)
+	assert.Equal(t, [][]any{
+		{uint(1), "First", "A", "9780134190440", uint(2), uint(10)},
+		{uint(3), "Third", "C", "", uint(1), uint(30)},
+	}, collectRows(t, table))
+}
+
+func TestExportService_Authors(t *testing.T) {
+	// Arrange
+	service := NewExportService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	table, err := service.Authors(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "authors", table.Name)
+	rows := collectRows(t, table)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []any{"Robert C. Martin", uint(1), uint(15000), uint(50), uint(50), float64(50)}, rows[0])
+}
+
+func TestExportService_StopsOnYieldError(t *testing.T) {
+	// Arrange
+	service := NewExportService(mockImpls.NewMockBooksRepositories())
+	table, _ := service.Books(context.Background())
+	closed := errors.New("client went away")
+	calls := 0
+
+	// Act
+	err := table.Rows(func([]any) error {
+		calls++
+		return closed
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, closed)
+	assert.Equal(t, 1, calls)
+}
+
+func TestExportService_Errors(t *testing.T) {
+	// Arrange
+	upstream := errors.New("connection reset")
+	service := NewExportService(&failingBooksRepository{err: upstream})
+
+	// Act
+	_, booksErr := service.Books(context.Background())
+	_, authorsErr := service.Authors(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, booksErr, ErrExternalServiceFailure)
+	assert.ErrorIs(t, booksErr, upstream)
+	assert.ErrorIs(t, authorsErr, ErrExternalServiceFailure)
+}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 
 	"educabot.com/bookshop/models"
@@ -11,11 +13,35 @@ import (
 
 var ErrExternalServiceFailure = errors.New("error fetching books from external service")
 var ErrBookNotFound = errors.New("book not found")
+var ErrInvalidMetricsOptions = errors.New("invalid metrics options")
+
+const maxTopN = 100
+
+// CheapestFormat selects how the cheapest book is reported besides the
+// cheapest_book name, which is always present.
+type CheapestFormat string
+
+const (
+	CheapestName CheapestFormat = "name"
+	CheapestBook CheapestFormat = "book"
+	CheapestTies CheapestFormat = "ties"
+)
+
+type MetricsOptions struct {
+	Author   string
+	Cheapest CheapestFormat
+	// TopN, when positive, adds the N cheapest and N best-selling books.
+	TopN int
+}
 
 type MetricsResult struct {
-	MeanUnitsSold        uint   `json:"mean_units_sold"`
-	CheapestBook         string `json:"cheapest_book"`
-	BooksWrittenByAuthor uint   `json:"books_written_by_author"`
+	MeanUnitsSold        uint          `json:"mean_units_sold"`
+	CheapestBook         string        `json:"cheapest_book"`
+	BooksWrittenByAuthor uint          `json:"books_written_by_author"`
+	CheapestBookDetail   *models.Book  `json:"cheapest_book_detail,omitempty"`
+	CheapestBooks        []models.Book `json:"cheapest_books,omitempty"`
+	TopCheapest          []models.Book `json:"top_cheapest,omitempty"`
+	TopSellers           []models.Book `json:"top_sellers,omitempty"`
 }
 
 type MetricsService struct {
@@ -27,17 +53,53 @@ func NewMetricsService(repository repositories.BooksRepository) *MetricsService
 }
 
 func (s *MetricsService) ComputeMetrics(ctx context.Context, author string) (*MetricsResult, error) {
+	return s.ComputeMetricsWithOptions(ctx, MetricsOptions{Author: author})
+}
+
+func (s *MetricsService) ComputeMetricsWithOptions(ctx context.Context, options MetricsOptions) (*MetricsResult, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
 	books, err := s.booksRepositories.GetBooksProvider(ctx)
 	if err != nil {
-		return nil, ErrExternalServiceFailure
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
 	}
+	return s.compute(books, options), nil
+}
 
+func (o MetricsOptions) validate() error {
+	switch o.Cheapest {
+	case "", CheapestName, CheapestBook, CheapestTies:
+	default:
+		return fmt.Errorf("%w: cheapest must be name, book or ties", ErrInvalidMetricsOptions)
+	}
+	if o.TopN < 0 || o.TopN > maxTopN {
+		return fmt.Errorf("%w: top_n must be between 0 and %d", ErrInvalidMetricsOptions, maxTopN)
+	}
+	return nil
+}
+
+// compute expects options that passed validate.
+func (s *MetricsService) compute(books []models.Book, options MetricsOptions) *MetricsResult {
+	cheapest := s.cheapestBook(books)
 	result := &MetricsResult{
 		MeanUnitsSold:        s.meanUnitsSold(books),
-		CheapestBook:         s.cheapestBook(books).Name,
-		BooksWrittenByAuthor: s.booksWrittenByAuthor(books, author),
+		CheapestBook:         cheapest.Name,
+		BooksWrittenByAuthor: s.booksWrittenByAuthor(books, options.Author),
+	}
+	switch {
+	case len(books) == 0:
+	case options.Cheapest == CheapestBook:
+		result.CheapestBookDetail = &cheapest
+	case options.Cheapest == CheapestTies:
+		result.CheapestBooks = s.cheapestBooks(books)
 	}
-	return result, nil
+	if options.TopN > 0 {
+		result.TopCheapest = s.topBooks(books, options.TopN, compareByPrice)
+		result.TopSellers = s.topBooks(books, options.TopN, compareBySales)
+	}
+	return result
 }
 
 func (s *MetricsService) meanUnitsSold(books []models.Book) uint {
@@ -51,13 +113,60 @@ func (s *MetricsService) meanUnitsSold(books []models.Book) uint {
 	return sum / uint(len(books))
 }
 
+// compareByIdentity breaks ties deterministically, whatever order the
+// upstream returned the catalog in.
+func compareByIdentity(a, b models.Book) int {
+	if c := cmp.Compare(a.ID, b.ID); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Name, b.Name)
+}
+
+func compareByPrice(a, b models.Book) int {
+	if c := cmp.Compare(a.Price, b.Price); c != 0 {
+		return c
+	}
+	return compareByIdentity(a, b)
+}
+
+func compareBySales(a, b models.Book) int {
+	if c := cmp.Compare(b.UnitsSold, a.UnitsSold); c != 0 {
+		return c
+	}
+	return compareByIdentity(a, b)
+}
+
 func (s *MetricsService) cheapestBook(books []models.Book) models.Book {
 	if len(books) == 0 {
 		return models.Book{}
 	}
-	return slices.MinFunc(books, func(a, b models.Book) int {
-		return int(a.Price - b.Price)
-	})
+	return slices.MinFunc(books, compareByPrice)
+}
+
+// cheapestBooks returns every book sharing the lowest price, ordered by ID and
+// then name.
+func (s *MetricsService) cheapestBooks(books []models.Book) []models.Book {
+	if len(books) == 0 {
+		return []models.Book{}
+	}
+	lowest := s.cheapestBook(books).Price
+	var ties []models.Book
+	for _, book := range books {
+		if book.Price == lowest {
+			ties = append(ties, book)
+		}
+	}
+	slices.SortFunc(ties, compareByIdentity)
+	return ties
+}
+
+func (s *MetricsService) topBooks(books []models.Book, n int, compare func(a, b models.Book) int) []models.Book {
+	sorted := slices.Clone(books)
+	slices.SortFunc(sorted, compare)
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
 }
 
 func (s *MetricsService) booksWrittenByAuthor(books []models.Book, author string) uint {
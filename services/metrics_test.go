@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
 	"educabot.com/bookshop/repositories/mockImpls"
+	"educabot.com/bookshop/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -74,10 +76,24 @@ func TestMetricsService_ComputeMetrics_RepositoryError(t *testing.T) {
 
 	// Assert
 	assert.Error(t, err)
-	assert.Equal(t, ErrExternalServiceFailure, err)
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
 	assert.Nil(t, result)
 }
 
+func TestMetricsService_ComputeMetrics_PreservesUpstreamCause(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(&failingBooksRepository{err: &repositories.UpstreamStatusError{Code: 503}})
+
+	// Act
+	_, err := service.ComputeMetrics(context.Background(), "")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	var statusErr *repositories.UpstreamStatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, 503, statusErr.Code)
+}
+
 func TestNewMetricsService(t *testing.T) {
 	// Arrange
 	mockRepo := mockImpls.NewMockBooksRepositories()
@@ -147,6 +163,111 @@ func TestMetricsService_cheapestBook_EmptySlice(t *testing.T) {
 	assert.Equal(t, models.Book{}, result)
 }
 
+func TestMetricsService_cheapestBook_TieIsDeterministic(t *testing.T) {
+	// Arrange
+	service := &MetricsService{}
+	books := []models.Book{
+		{ID: 7, Name: "Later Tie", Price: 20},
+		{ID: 3, Name: "Expensive", Price: 90},
+		{ID: 2, Name: "Earlier Tie", Price: 20},
+	}
+	reversed := []models.Book{books[2], books[1], books[0]}
+
+	// Act
+	result := service.cheapestBook(books)
+	resultReversed := service.cheapestBook(reversed)
+
+	// Assert
+	assert.Equal(t, "Earlier Tie", result.Name)
+	assert.Equal(t, result, resultReversed)
+}
+
+func TestMetricsService_cheapestBooks(t *testing.T) {
+	// Arrange
+	service := &MetricsService{}
+	books := []models.Book{
+		{ID: 7, Name: "B", Price: 20},
+		{ID: 3, Name: "Expensive", Price: 90},
+		{ID: 2, Name: "Z", Price: 20},
+		{ID: 2, Name: "A", Price: 20},
+	}
+
+	// Act
+	result := service.cheapestBooks(books)
+
+	// Assert
+	assert.Equal(t, []models.Book{
+		{ID: 2, Name: "A", Price: 20},
+		{ID: 2, Name: "Z", Price: 20},
+		{ID: 7, Name: "B", Price: 20},
+	}, result)
+}
+
+func TestMetricsService_cheapestBooks_EmptySlice(t *testing.T) {
+	// Arrange
+	service := &MetricsService{}
+
+	// Act
+	result := service.cheapestBooks(nil)
+
+	// Assert
+	assert.Empty(t, result)
+}
+
+func TestMetricsService_ComputeMetricsWithOptions_CheapestBook(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	result, err := service.ComputeMetricsWithOptions(context.Background(), MetricsOptions{Cheapest: CheapestBook})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "The Go Programming Language", result.CheapestBook)
+	assert.Equal(t, uint(1), result.CheapestBookDetail.ID)
+	assert.Nil(t, result.CheapestBooks)
+	assert.Nil(t, result.TopCheapest)
+}
+
+func TestMetricsService_ComputeMetricsWithOptions_TiesAndTopN(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(&testutil.StaticBooksRepository{Books: []models.Book{
+		{ID: 4, Name: "D", Price: 10, UnitsSold: 50},
+		{ID: 1, Name: "A", Price: 30, UnitsSold: 900},
+		{ID: 3, Name: "C", Price: 10, UnitsSold: 50},
+		{ID: 2, Name: "B", Price: 20, UnitsSold: 100},
+	}})
+
+	// Act
+	result, err := service.ComputeMetricsWithOptions(context.Background(), MetricsOptions{Cheapest: CheapestTies, TopN: 3})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "C", result.CheapestBook)
+	assert.Nil(t, result.CheapestBookDetail)
+	assert.Equal(t, []uint{3, 4}, bookIDs(result.CheapestBooks))
+	assert.Equal(t, []uint{3, 4, 2}, bookIDs(result.TopCheapest))
+	assert.Equal(t, []uint{1, 2, 3}, bookIDs(result.TopSellers))
+}
+
+func TestMetricsService_ComputeMetricsWithOptions_Invalid(t *testing.T) {
+	// Arrange
+	service := NewMetricsService(mockImpls.NewMockBooksRepositories())
+
+	for _, options := range []MetricsOptions{
+		{Cheapest: "all"},
+		{TopN: -1},
+		{TopN: 1000},
+	} {
+		// Act
+		result, err := service.ComputeMetricsWithOptions(context.Background(), options)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidMetricsOptions)
+		assert.Nil(t, result)
+	}
+}
+
 func TestMetricsService_booksWrittenByAuthor(t *testing.T) {
 	// Arrange
 	service := &MetricsService{}
@@ -180,8 +301,24 @@ func TestMetricsService_booksWrittenByAuthor_NoMatches(t *testing.T) {
 }
 
 // Mock repository that returns an error for testing error scenarios
+type failingBooksRepository struct {
+	err error
+}
+
+func (r *failingBooksRepository) GetBooksProvider(ctx context.Context) ([]models.Book, error) {
+	return nil, r.err
+}
+
 type MockBooksRepositoryWithError struct{}
 
 func (m *MockBooksRepositoryWithError) GetBooksProvider(ctx context.Context) ([]models.Book, error) {
 	return nil, errors.New("repository error")
 }
+
+func bookIDs(books []models.Book) []uint {
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	return ids
+}
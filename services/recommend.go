@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+var ErrInvalidRecommendationWeights = errors.New("invalid recommendation weights")
+
+type RecommendationWeights struct {
+	SameAuthor   float64 `json:"same_author"`
+	PriceBand    float64 `json:"price_band"`
+	SimilarSales float64 `json:"similar_sales"`
+	CoPurchase   float64 `json:"co_purchase"`
+}
+
+type RecommenderOptions struct {
+	Weights RecommendationWeights
+	// PriceBandRatio is how far, relative to the reference price, another
+	// book's price may be to count as the same price band.
+	PriceBandRatio float64
+	// SalesRatio is the equivalent tolerance for units sold.
+	SalesRatio float64
+}
+
+func DefaultRecommenderOptions() RecommenderOptions {
+	return RecommenderOptions{
+		Weights: RecommendationWeights{
+			SameAuthor:   3,
+			PriceBand:    1,
+			SimilarSales: 1,
+			CoPurchase:   2,
+		},
+		PriceBandRatio: 0.2,
+		SalesRatio:     0.25,
+	}
+}
+
+// ParseRecommendationWeights reads weights in the form
+// "author=3,price=1,sales=1,copurchase=2". Omitted keys keep their value in
+// base.
+func ParseRecommendationWeights(spec string, base RecommendationWeights) (RecommendationWeights, error) {
+	weights := base
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			return base, fmt.Errorf("%w: %q is not key=value", ErrInvalidRecommendationWeights, pair)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || value < 0 {
+			return base, fmt.Errorf("%w: %q must be a non-negative number", ErrInvalidRecommendationWeights, pair)
+		}
+		switch strings.TrimSpace(key) {
+		case "author":
+			weights.SameAuthor = value
+		case "price":
+			weights.PriceBand = value
+		case "sales":
+			weights.SimilarSales = value
+		case "copurchase":
+			weights.CoPurchase = value
+		default:
+			return base, fmt.Errorf("%w: unknown key %q", ErrInvalidRecommendationWeights, key)
+		}
+	}
+	return weights, nil
+}
+
+type Recommendation struct {
+	Book    models.Book `json:"book"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons"`
+}
+
+// CoPurchase records how many orders contained both books.
+type CoPurchase struct {
+	BookID     uint `json:"book_id"`
+	AlsoBought uint `json:"also_bought"`
+	Count      uint `json:"count"`
+}
+
+type Recommender struct {
+	booksRepositories repositories.BooksRepository
+	options           RecommenderOptions
+
+	mu          sync.RWMutex
+	coPurchases map[uint]map[uint]uint
+}
+
+func NewRecommender(repository repositories.BooksRepository, options RecommenderOptions) *Recommender {
+	return &Recommender{booksRepositories: repository, options: options}
+}
+
+// LoadCoPurchases replaces the co-purchase dataset with the JSON array read
+// from reader.
+func (r *Recommender) LoadCoPurchases(reader io.Reader) error {
+	pairs, err := ParseCoPurchases(reader)
+	if err != nil {
+		return err
+	}
+	r.SetCoPurchases(pairs)
+	return nil
+}
+
+// ParseCoPurchases decodes a JSON array of CoPurchase.
+func ParseCoPurchases(reader io.Reader) ([]CoPurchase, error) {
+	var pairs []CoPurchase
+	if err := json.NewDecoder(reader).Decode(&pairs); err != nil {
+		return nil, fmt.Errorf("decoding co-purchases: %w", err)
+	}
+	return pairs, nil
+}
+
+// SetCoPurchases replaces the co-purchase dataset. Pairs are symmetric:
+// buying A with B also counts for B.
+func (r *Recommender) SetCoPurchases(pairs []CoPurchase) {
+	coPurchases := make(map[uint]map[uint]uint)
+	add := func(a, b, count uint) {
+		if coPurchases[a] == nil {
+			coPurchases[a] = make(map[uint]uint)
+		}
+		coPurchases[a][b] += count
+	}
+	for _, pair := range pairs {
+		if pair.BookID == pair.AlsoBought || pair.Count == 0 {
+			continue
+		}
+		add(pair.BookID, pair.AlsoBought, pair.Count)
+		add(pair.AlsoBought, pair.BookID, pair.Count)
+	}
+
+	r.mu.Lock()
+	r.coPurchases = coPurchases
+	r.mu.Unlock()
+}
+
+func (r *Recommender) Similar(ctx context.Context, id uint, limit int) ([]Recommendation, error) {
+	books, err := r.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+
+	idx := slices.IndexFunc(books, func(book models.Book) bool { return book.ID == id })
+	if idx < 0 {
+		return nil, ErrBookNotFound
+	}
+	reference := books[idx]
+
+	r.mu.RLock()
+	alsoBought := r.coPurchases[reference.ID]
+	r.mu.RUnlock()
+	var maxCoPurchases uint
+	for _, count := range alsoBought {
+		maxCoPurchases = max(maxCoPurchases, count)
+	}
+
+	weights := r.options.Weights
+	recommendations := []Recommendation{}
+	for _, book := range books {
+		if book.ID == reference.ID {
+			continue
+		}
+
+		var score float64
+		var reasons []string
+		if weights.SameAuthor > 0 && book.Author != "" && book.Author == reference.Author {
+			score += weights.SameAuthor
+			reasons = append(reasons, fmt.Sprintf("same author: %s", book.Author))
+		}
+		if closeness := relativeCloseness(reference.Price, book.Price, r.options.PriceBandRatio); weights.PriceBand > 0 && closeness > 0 {
+			score += weights.PriceBand * closeness
+			reasons = append(reasons, fmt.Sprintf("similar price: %d vs %d", book.Price, reference.Price))
+		}
+		if closeness := relativeCloseness(reference.UnitsSold, book.UnitsSold, r.options.SalesRatio); weights.SimilarSales > 0 && closeness > 0 {
+			score += weights.SimilarSales * closeness
+			reasons = append(reasons, fmt.Sprintf("comparable sales: %d vs %d units", book.UnitsSold, reference.UnitsSold))
+		}
+		if count := alsoBought[book.ID]; weights.CoPurchase > 0 && count > 0 {
+			score += weights.CoPurchase * float64(count) / float64(maxCoPurchases)
+			reasons = append(reasons, fmt.Sprintf("readers also bought: %d orders", count))
+		}
+
+		if score > 0 {
+			recommendations = append(recommendations, Recommendation{Book: book, Score: score, Reasons: reasons})
+		}
+	}
+
+	sort.Slice(recommendations, func(a, b int) bool {
+		if recommendations[a].Score != recommendations[b].Score {
+			return recommendations[a].Score > recommendations[b].Score
+		}
+		return recommendations[a].Book.ID < recommendations[b].Book.ID
+	})
+	if limit > 0 && len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+// relativeCloseness is 1 when value equals reference, falls linearly to 0.5 at
+// a relative distance of ratio and is 0 beyond it.
+func relativeCloseness(reference, value uint, ratio float64) float64 {
+	if ratio <= 0 || reference == 0 {
+		return 0
+	}
+	distance := math.Abs(float64(value)-float64(reference)) / float64(reference)
+	if distance > ratio {
+		return 0
+	}
+	return 1 - distance/(ratio*2)
+}
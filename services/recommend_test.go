@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func recommendationCatalog() *testutil.StaticBooksRepository {
+	return &testutil.StaticBooksRepository{Books: []models.Book{
+		{ID: 1, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50},
+		{ID: 2, Name: "Clean Architecture", Author: "Robert C. Martin", UnitsSold: 4000, Price: 90},
+		{ID: 3, Name: "Refactoring", Author: "Martin Fowler", UnitsSold: 14000, Price: 48},
+		{ID: 4, Name: "Cheap Pamphlet", Author: "Anon", UnitsSold: 10, Price: 5},
+		{ID: 5, Name: "Domain-Driven Design", Author: "Eric Evans", UnitsSold: 100, Price: 200},
+	}}
+}
+
+func TestRecommender_Similar_AuthorPriceAndSales(t *testing.T) {
+	// Arrange
+	recommender := NewRecommender(recommendationCatalog(), DefaultRecommenderOptions())
+
+	// Act
+	recommendations, err := recommender.Similar(context.Background(), 1, 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 2)
+	assert.Equal(t, uint(2), recommendations[0].Book.ID)
+	assert.Equal(t, []string{"same author: Robert C. Martin"}, recommendations[0].Reasons)
+	assert.Equal(t, uint(3), recommendations[1].Book.ID)
+	assert.Equal(t, []string{
+		"similar price: 48 vs 50",
+		"comparable sales: 14000 vs 15000 units",
+	}, recommendations[1].Reasons)
+}
+
+func TestRecommender_Similar_CoPurchases(t *testing.T) {
+	// Arrange
+	recommender := NewRecommender(recommendationCatalog(), DefaultRecommenderOptions())
+	err := recommender.LoadCoPurchases(strings.NewReader(`[
+		{"book_id": 5, "also_bought": 1, "count": 8},
+		{"book_id": 1, "also_bought": 4, "count": 2}
+	]`))
+	assert.NoError(t, err)
+
+	// Act
+	recommendations, err := recommender.Similar(context.Background(), 1, 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 4)
+	assert.Equal(t, uint(2), recommendations[0].Book.ID)
+	assert.Equal(t, uint(5), recommendations[1].Book.ID)
+	assert.Equal(t, []string{"readers also bought: 8 orders"}, recommendations[1].Reasons)
+	assert.InDelta(t, 2.0, recommendations[1].Score, 1e-9)
+}
+
+func TestRecommender_Similar_CustomWeights(t *testing.T) {
+	// Arrange
+	options := DefaultRecommenderOptions()
+	options.Weights.SameAuthor = 0
+	recommender := NewRecommender(recommendationCatalog(), options)
+
+	// Act
+	recommendations, err := recommender.Similar(context.Background(), 1, 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+	assert.Equal(t, uint(3), recommendations[0].Book.ID)
+}
+
+func TestRecommender_Similar_Limit(t *testing.T) {
+	// Arrange
+	recommender := NewRecommender(recommendationCatalog(), DefaultRecommenderOptions())
+
+	// Act
+	recommendations, err := recommender.Similar(context.Background(), 1, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+}
+
+func TestRecommender_Similar_NotFound(t *testing.T) {
+	// Arrange
+	recommender := NewRecommender(recommendationCatalog(), DefaultRecommenderOptions())
+
+	// Act
+	recommendations, err := recommender.Similar(context.Background(), 99, 10)
+
+	// Assert
+	assert.Equal(t, ErrBookNotFound, err)
+	assert.Nil(t, recommendations)
+}
+
+func TestRecommender_Similar_RepositoryError(t *testing.T) {
+	// Arrange
+	recommender := NewRecommender(&MockBooksRepositoryWithError{}, DefaultRecommenderOptions())
+
+	// Act
+	recommendations, err := recommender.Similar(context.Background(), 1, 10)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	assert.Nil(t, recommendations)
+}
+
+func TestRecommender_LoadCoPurchases_InvalidJSON(t *testing.T) {
+	// Arrange
+	recommender := NewRecommender(recommendationCatalog(), DefaultRecommenderOptions())
+
+	// Act
+	err := recommender.LoadCoPurchases(strings.NewReader("{"))
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestParseRecommendationWeights(t *testing.T) {
+	// Arrange
+	base := DefaultRecommenderOptions().Weights
+
+	// Act
+	weights, err := ParseRecommendationWeights("author=5, copurchase=0.5", base)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, RecommendationWeights{SameAuthor: 5, PriceBand: 1, SimilarSales: 1, CoPurchase: 0.5}, weights)
+}
+
+func TestParseRecommendationWeights_Invalid(t *testing.T) {
+	for _, spec := range []string{"author", "author=-1", "author=abc", "isbn=1"} {
+		// Act
+		_, err := ParseRecommendationWeights(spec, RecommendationWeights{})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidRecommendationWeights, spec)
+	}
+}
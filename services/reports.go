@@ -0,0 +1,127 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+const defaultReportTopN = 10
+
+type AuthorSummary struct {
+	Author    string  `json:"author"`
+	Books     uint    `json:"books"`
+	UnitsSold uint    `json:"units_sold"`
+	MinPrice  uint    `json:"min_price"`
+	MaxPrice  uint    `json:"max_price"`
+	MeanPrice float64 `json:"mean_price"`
+}
+
+type SummaryReport struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Author      string          `json:"author,omitempty"`
+	Metrics     *MetricsResult  `json:"metrics"`
+	Authors     []AuthorSummary `json:"authors"`
+}
+
+type ReportOptions struct {
+	// Author is counted in Metrics.BooksWrittenByAuthor.
+	Author string
+	// TopN is the length of the top-sellers list; zero means 10.
+	TopN int
+}
+
+type ReportsService struct {
+	booksRepositories repositories.BooksRepository
+	metrics           *MetricsService
+	// Now stamps GeneratedAt when the repository cannot tell when its
+	// catalog last changed; nil means time.Now.
+	Now func() time.Time
+}
+
+// catalogClock is implemented by repositories that know when their catalog
+// last changed, such as repositories.CatalogRefresher.
+type catalogClock interface {
+	LastRefresh() time.Time
+}
+
+func NewReportsService(repository repositories.BooksRepository) *ReportsService {
+	return &ReportsService{booksRepositories: repository, metrics: NewMetricsService(repository)}
+}
+
+// Summary builds the metrics, per-author table and top sellers from a single
+// read of the catalog.
+func (s *ReportsService) Summary(ctx context.Context, options ReportOptions) (*SummaryReport, error) {
+	metricsOptions := MetricsOptions{Author: options.Author, Cheapest: CheapestBook, TopN: options.TopN}
+	if metricsOptions.TopN == 0 {
+		metricsOptions.TopN = defaultReportTopN
+	}
+	if err := metricsOptions.validate(); err != nil {
+		return nil, err
+	}
+
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+
+	return &SummaryReport{
+		GeneratedAt: s.generatedAt().UTC(),
+		Author:      options.Author,
+		Metrics:     s.metrics.compute(books, metricsOptions),
+		Authors:     SummarizeAuthors(books),
+	}, nil
+}
+
+// generatedAt is the time of the catalog the report was built from, so the
+// report and its ETag only change with the catalog.
+func (s *ReportsService) generatedAt() time.Time {
+	if clock, ok := s.booksRepositories.(catalogClock); ok {
+		if at := clock.LastRefresh(); !at.IsZero() {
+			return at
+		}
+	}
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// SummarizeAuthors aggregates books per author, best sellers first and then
+// by author name.
+func SummarizeAuthors(books []models.Book) []AuthorSummary {
+	index := map[string]int{}
+	summaries := []AuthorSummary{}
+	var priceTotals []uint
+	for _, book := range books {
+		i, ok := index[book.Author]
+		if !ok {
+			i = len(summaries)
+			index[book.Author] = i
+			summaries = append(summaries, AuthorSummary{Author: book.Author, MinPrice: book.Price, MaxPrice: book.Price})
+			priceTotals = append(priceTotals, 0)
+		}
+		summary := &summaries[i]
+		summary.Books++
+		summary.UnitsSold += book.UnitsSold
+		summary.MinPrice = min(summary.MinPrice, book.Price)
+		summary.MaxPrice = max(summary.MaxPrice, book.Price)
+		priceTotals[i] += book.Price
+	}
+	for i := range summaries {
+		summaries[i].MeanPrice = float64(priceTotals[i]) / float64(summaries[i].Books)
+	}
+
+	slices.SortFunc(summaries, func(a, b AuthorSummary) int {
+		if c := cmp.Compare(b.UnitsSold, a.UnitsSold); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Author, b.Author)
+	})
+	return summaries
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportsService_Summary(t *testing.T) {
+	// Arrange
+	service := NewReportsService(mockImpls.NewMockBooksRepositories())
+	generatedAt := time.Date(2026, time.March, 1, 9, 30, 0, 0, time.FixedZone("ART", -3*60*60))
+	service.Now = func() time.Time { return generatedAt }
+
+	// Act
+	report, err := service.Summary(context.Background(), ReportOptions{Author: "Andrew Hunt", TopN: 2})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, generatedAt.UTC(), report.GeneratedAt)
+	assert.Equal(t, "Andrew Hunt", report.Author)
+	assert.Equal(t, uint(11000), report.Metrics.MeanUnitsSold)
+	assert.Equal(t, uint(1), report.Metrics.BooksWrittenByAuthor)
+	assert.Equal(t, "The Go Programming Language", report.Metrics.CheapestBookDetail.Name)
+	assert.Len(t, report.Metrics.TopSellers, 2)
+	assert.Equal(t, "Clean Code", report.Metrics.TopSellers[0].Name)
+	assert.Len(t, report.Authors, 3)
+}
+
+func TestReportsService_SummaryDefaultsTopN(t *testing.T) {
+	// Arrange
+	service := NewReportsService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	report, err := service.Summary(context.Background(), ReportOptions{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, report.Metrics.TopSellers, 3)
+	assert.False(t, report.GeneratedAt.IsZero())
+}
+
+// clockedRepository is a catalog that knows when it last changed.
+type clockedRepository struct {
+	books       []models.Book
+	lastRefresh time.Time
+}
+
+func (r *clockedRepository) GetBooksProvider(context.Context) ([]models.Book, error) {
+	return r.books, nil
+}
+
+func (r *clockedRepository) LastRefresh() time.Time {
+	return r.lastRefresh
+}
+
+func TestReportsService_SummaryGeneratedAtFollowsCatalog(t *testing.T) {
+	changedAt := time.Date(2026, time.March, 1, 9, 30, 0, 0, time.FixedZone("ART", -3*60*60))
+	now := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	books := []models.Book{{ID: 1, Author: "Alan Donovan", Price: 40, UnitsSold: 5}}
+
+	tests := []struct {
+		name        string
+		lastRefresh time.Time
+		want        time.Time
+	}{
+		{"catalog time", changedAt, changedAt.UTC()},
+		{"catalog not loaded", time.Time{}, now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service := NewReportsService(&clockedRepository{books: books, lastRefresh: tt.lastRefresh})
+			service.Now = func() time.Time { return now }
+
+			// Act
+			first, err1 := service.Summary(context.Background(), ReportOptions{})
+			second, err2 := service.Summary(context.Background(), ReportOptions{})
+
+			// Assert
+			assert.NoError(t, err1)
+			assert.NoError(t, err2)
+			assert.Equal(t, tt.want, first.GeneratedAt)
+			assert.Equal(t, first, second)
+		})
+	}
+}
+
+func TestReportsService_SummaryErrors(t *testing.T) {
+	// Arrange
+	upstream := errors.New("connection reset")
+	failing := NewReportsService(&failingBooksRepository{err: upstream})
+	working := NewReportsService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	_, fetchErr := failing.Summary(context.Background(), ReportOptions{})
+	_, optionsErr := working.Summary(context.Background(), ReportOptions{TopN: 101})
+
+	// Assert
+	assert.ErrorIs(t, fetchErr, ErrExternalServiceFailure)
+	assert.ErrorIs(t, fetchErr, upstream)
+	assert.ErrorIs(t, optionsErr, ErrInvalidMetricsOptions)
+}
+
+func TestSummarizeAuthors(t *testing.T) {
+	// Arrange
+	books := []models.Book{
+		{ID: 1, Author: "B", UnitsSold: 10, Price: 30},
+		{ID: 2, Author: "A", UnitsSold: 5, Price: 10},
+		{ID: 3, Author: "B", UnitsSold: 20, Price: 15},
+		{ID: 4, Author: "C", UnitsSold: 5, Price: 7},
+	}
+
+	// Act
+	summaries := SummarizeAuthors(books)
+
+	// Assert
+	assert.Equal(t, []AuthorSummary{
+		{Author: "B", Books: 2, UnitsSold: 30, MinPrice: 15, MaxPrice: 30, MeanPrice: 22.5},
+		{Author: "A", Books: 1, UnitsSold: 5, MinPrice: 10, MaxPrice: 10, MeanPrice: 10},
+		{Author: "C", Books: 1, UnitsSold: 5, MinPrice: 7, MaxPrice: 7, MeanPrice: 7},
+	}, summaries)
+	assert.Equal(t, []AuthorSummary{}, SummarizeAuthors(nil))
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+var ErrEmptyQuery = errors.New("search query is empty")
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// prefixMatchWeight discounts terms that only match a query token as a
+	// prefix, so "pragmatic" ranks an exact hit above "pragmatics".
+	prefixMatchWeight = 0.8
+)
+
+type SearchHit struct {
+	Book       models.Book     `json:"book"`
+	Score      float64         `json:"score"`
+	Highlights SearchHighlight `json:"highlights"`
+}
+
+// SearchHighlight holds HTML-escaped copies of the matched fields with every
+// matching word wrapped in <mark>.
+type SearchHighlight struct {
+	Name   string `json:"name"`
+	Author string `json:"author"`
+}
+
+type SearchResult struct {
+	Query string      `json:"query"`
+	Total int         `json:"total"`
+	Hits  []SearchHit `json:"hits"`
+}
+
+type searchDoc struct {
+	book   models.Book
+	length int
+}
+
+// SearchIndex is an inverted index over book titles and authors ranked with
+// BM25. Documents are keyed by their position in the catalog, so books
+// without an ID or sharing one are all searchable. It is safe for concurrent
+// use.
+type SearchIndex struct {
+	mu          sync.RWMutex
+	docs        map[int]*searchDoc
+	postings    map[string]map[int]int
+	totalLength int
+	terms       []string
+	termsDirty  bool
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     make(map[int]*searchDoc),
+		postings: make(map[string]map[int]int),
+	}
+}
+
+// Sync brings the index in line with books, re-indexing only the positions
+// whose title or author changed.
+func (idx *SearchIndex) Sync(books []models.Book) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for pos, book := range books {
+		doc, ok := idx.docs[pos]
+		if ok && doc.book.Name == book.Name && doc.book.Author == book.Author {
+			doc.book = book
+			continue
+		}
+		if ok {
+			idx.remove(pos, doc)
+		}
+		idx.add(pos, book)
+	}
+	for pos, doc := range idx.docs {
+		if pos >= len(books) {
+			idx.remove(pos, doc)
+		}
+	}
+
+	if idx.termsDirty {
+		idx.terms = idx.terms[:0]
+		for term := range idx.postings {
+			idx.terms = append(idx.terms, term)
+		}
+		sort.Strings(idx.terms)
+		idx.termsDirty = false
+	}
+}
+
+func (idx *SearchIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+func (idx *SearchIndex) add(pos int, book models.Book) {
+	doc := &searchDoc{book: book}
+	for _, field := range []string{book.Name, book.Author} {
+		for _, tok := range tokenize(field) {
+			postings, ok := idx.postings[tok.term]
+			if !ok {
+				postings = make(map[int]int)
+				idx.postings[tok.term] = postings
+				idx.termsDirty = true
+			}
+			postings[pos]++
+			doc.length++
+		}
+	}
+	idx.docs[pos] = doc
+	idx.totalLength += doc.length
+}
+
+func (idx *SearchIndex) remove(pos int, doc *searchDoc) {
+	for _, field := range []string{doc.book.Name, doc.book.Author} {
+		for _, tok := range tokenize(field) {
+			postings := idx.postings[tok.term]
+			delete(postings, pos)
+			if len(postings) == 0 {
+				delete(idx.postings, tok.term)
+				idx.termsDirty = true
+			}
+		}
+	}
+	delete(idx.docs, pos)
+	idx.totalLength -= doc.length
+}
+
+// expand returns every indexed term starting with prefix, weighted so that the
+// exact term scores higher than longer completions.
+func (idx *SearchIndex) expand(prefix string) map[string]float64 {
+	expanded := make(map[string]float64)
+	start := sort.SearchStrings(idx.terms, prefix)
+	for _, term := range idx.terms[start:] {
+		if !strings.HasPrefix(term, prefix) {
+			break
+		}
+		if term == prefix {
+			expanded[term] = 1
+		} else {
+			expanded[term] = prefixMatchWeight
+		}
+	}
+	return expanded
+}
+
+func (idx *SearchIndex) Search(query string, limit int) []SearchHit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.docs) == 0 {
+		return []SearchHit{}
+	}
+
+	// Each indexed term counts once, with its best weight, however many
+	// query tokens expand to it.
+	matched := make(map[string]float64)
+	for _, tok := range tokenize(query) {
+		for term, weight := range idx.expand(tok.term) {
+			matched[term] = max(matched[term], weight)
+		}
+	}
+
+	avgLength := float64(idx.totalLength) / float64(len(idx.docs))
+	scores := make(map[int]float64)
+	for term, weight := range matched {
+		postings := idx.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (float64(len(idx.docs))-df+0.5)/(df+0.5))
+		for pos, tf := range postings {
+			lengthNorm := 1 - bm25B + bm25B*float64(idx.docs[pos].length)/avgLength
+			scores[pos] += weight * idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*lengthNorm)
+		}
+	}
+
+	positions := make([]int, 0, len(scores))
+	for pos := range scores {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+	hits := make([]SearchHit, 0, len(scores))
+	for _, pos := range positions {
+		book := idx.docs[pos].book
+		hits = append(hits, SearchHit{
+			Book:  book,
+			Score: scores[pos],
+			Highlights: SearchHighlight{
+				Name:   highlight(book.Name, matched),
+				Author: highlight(book.Author, matched),
+			},
+		})
+	}
+	sort.SliceStable(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Book.ID < hits[b].Book.ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+func highlight(text string, terms map[string]float64) string {
+	var sb strings.Builder
+	last := 0
+	for _, tok := range tokenize(text) {
+		if _, ok := terms[tok.term]; !ok {
+			continue
+		}
+		sb.WriteString(html.EscapeString(text[last:tok.start]))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(text[tok.start:tok.end]))
+		sb.WriteString("</mark>")
+		last = tok.end
+	}
+	sb.WriteString(html.EscapeString(text[last:]))
+	return sb.String()
+}
+
+type SearchService struct {
+	booksRepositories repositories.BooksRepository
+	index             *SearchIndex
+
+	mu       sync.Mutex
+	lastSeen []models.Book
+}
+
+func NewSearchService(repository repositories.BooksRepository) *SearchService {
+	return &SearchService{booksRepositories: repository, index: NewSearchIndex()}
+}
+
+func (s *SearchService) Search(ctx context.Context, query string, limit int) (*SearchResult, error) {
+	if len(tokenize(query)) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+
+	s.mu.Lock()
+	if !sameCatalog(s.lastSeen, books) {
+		s.index.Sync(books)
+		s.lastSeen = books
+	}
+	s.mu.Unlock()
+
+	hits := s.index.Search(query, 0)
+	result := &SearchResult{Query: query, Total: len(hits), Hits: hits}
+	if limit > 0 && len(hits) > limit {
+		result.Hits = hits[:limit]
+	}
+	return result, nil
+}
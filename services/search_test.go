@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchIndex_Search_RanksExactMatchFirst(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	index.Sync([]models.Book{
+		{ID: 1, Name: "Pragmatics of Language", Author: "Jane Doe"},
+		{ID: 2, Name: "The Pragmatic Programmer", Author: "Andrew Hunt"},
+		{ID: 3, Name: "Clean Code", Author: "Robert C. Martin"},
+	})
+
+	// Act
+	hits := index.Search("pragmatic", 0)
+
+	// Assert
+	assert.Len(t, hits, 2)
+	assert.Equal(t, uint(2), hits[0].Book.ID)
+	assert.Equal(t, uint(1), hits[1].Book.ID)
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+}
+
+func TestSearchIndex_Search_PrefixAndAccentFolding(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	index.Sync([]models.Book{
+		{ID: 1, Name: "Cien años de soledad", Author: "Gabriel García Márquez"},
+	})
+
+	// Act
+	hits := index.Search("garc", 0)
+
+	// Assert
+	assert.Len(t, hits, 1)
+	assert.Equal(t, "Gabriel <mark>García</mark> Márquez", hits[0].Highlights.Author)
+	assert.Equal(t, "Cien años de soledad", hits[0].Highlights.Name)
+}
+
+func TestSearchIndex_Search_EscapesHighlights(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	index.Sync([]models.Book{{ID: 1, Name: "Go <Fast> & Go", Author: "Anon"}})
+
+	// Act
+	hits := index.Search("fast", 0)
+
+	// Assert
+	assert.Len(t, hits, 1)
+	assert.Equal(t, "Go &lt;<mark>Fast</mark>&gt; &amp; Go", hits[0].Highlights.Name)
+}
+
+func TestSearchIndex_Sync_Incremental(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	index.Sync([]models.Book{
+		{ID: 1, Name: "Clean Code", Author: "Robert C. Martin"},
+		{ID: 2, Name: "Refactoring", Author: "Martin Fowler"},
+	})
+
+	// Act
+	index.Sync([]models.Book{
+		{ID: 1, Name: "Clean Architecture", Author: "Robert C. Martin"},
+		{ID: 3, Name: "Domain-Driven Design", Author: "Eric Evans"},
+	})
+
+	// Assert
+	assert.Equal(t, 2, index.Len())
+	assert.Empty(t, index.Search("code", 0))
+	assert.Empty(t, index.Search("refactoring", 0))
+	assert.Len(t, index.Search("architecture", 0), 1)
+	assert.Len(t, index.Search("evans", 0), 1)
+}
+
+func TestSearchIndex_Sync_BooksWithoutUniqueIDs(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	books := []models.Book{
+		{Name: "Clean Code", Author: "Robert C. Martin"},
+		{Name: "Clean Architecture", Author: "Robert C. Martin"},
+		{ID: 7, Name: "Clean Agile", Author: "Robert C. Martin"},
+		{ID: 7, Name: "Clean Craftsmanship", Author: "Robert C. Martin"},
+	}
+
+	// Act
+	index.Sync(books)
+	all := index.Search("clean", 0)
+	index.Sync(books[1:3])
+	remaining := index.Search("clean", 0)
+
+	// Assert
+	assert.Len(t, all, 4)
+	assert.Equal(t, 2, index.Len())
+	var names []string
+	for _, hit := range remaining {
+		names = append(names, hit.Book.Name)
+	}
+	assert.ElementsMatch(t, []string{"Clean Architecture", "Clean Agile"}, names)
+}
+
+func TestSearchIndex_Search_RepeatedQueryTerms(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	index.Sync([]models.Book{
+		{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan"},
+		{ID: 2, Name: "Clean Code", Author: "Robert C. Martin"},
+	})
+
+	// Act
+	once := index.Search("go", 0)
+	repeated := index.Search("go go Go", 0)
+	overlapping := index.Search("prog programming", 0)
+	exact := index.Search("programming", 0)
+
+	// Assert
+	assert.Equal(t, once, repeated)
+	assert.Equal(t, exact[0].Score, overlapping[0].Score)
+}
+
+func TestSearchIndex_Search_Limit(t *testing.T) {
+	// Arrange
+	index := NewSearchIndex()
+	index.Sync([]models.Book{
+		{ID: 1, Name: "Go A", Author: "X"},
+		{ID: 2, Name: "Go B", Author: "Y"},
+		{ID: 3, Name: "Go C", Author: "Z"},
+	})
+
+	// Act
+	hits := index.Search("go", 2)
+
+	// Assert
+	assert.Len(t, hits, 2)
+}
+
+func TestSearchService_Search(t *testing.T) {
+	// Arrange
+	service := NewSearchService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	result, err := service.Search(context.Background(), "pragmatic", 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, "The Pragmatic Programmer", result.Hits[0].Book.Name)
+	assert.Equal(t, "The <mark>Pragmatic</mark> Programmer", result.Hits[0].Highlights.Name)
+}
+
+func TestSearchService_Search_EmptyQuery(t *testing.T) {
+	// Arrange
+	service := NewSearchService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	result, err := service.Search(context.Background(), "  ,, ", 10)
+
+	// Assert
+	assert.Equal(t, ErrEmptyQuery, err)
+	assert.Nil(t, result)
+}
+
+func TestSearchService_Search_RepositoryError(t *testing.T) {
+	// Arrange
+	service := NewSearchService(&MockBooksRepositoryWithError{})
+
+	// Act
+	result, err := service.Search(context.Background(), "go", 10)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+var ErrInvalidSuggestField = errors.New("field must be author or title")
+var ErrEmptyPrefix = errors.New("prefix is empty")
+
+type SuggestField string
+
+const (
+	SuggestAuthor SuggestField = "author"
+	SuggestTitle  SuggestField = "title"
+)
+
+type Suggestion struct {
+	Value     string `json:"value"`
+	UnitsSold uint   `json:"units_sold"`
+}
+
+type suggestKey struct {
+	key  string
+	rank int
+}
+
+// suggestList is a sorted prefix index over the distinct values of one field.
+// values is ordered by units sold, so a lower rank is a better suggestion and
+// the top results for a prefix are the smallest ranks within its key range.
+// minTree is a segment tree over keys answering "position of the lowest rank
+// in [lo, hi]", which keeps lookups logarithmic even for one-letter prefixes.
+type suggestList struct {
+	values  []Suggestion
+	keys    []suggestKey
+	minTree []int
+}
+
+// SuggestIndex answers typeahead lookups for authors and titles. Every word
+// boundary of a value is indexed, so "mart" suggests "Robert C. Martin".
+type SuggestIndex struct {
+	fields map[SuggestField]*suggestList
+}
+
+func NewSuggestIndex(books []models.Book) *SuggestIndex {
+	return &SuggestIndex{fields: map[SuggestField]*suggestList{
+		SuggestAuthor: newSuggestList(books, func(b models.Book) string { return b.Author }),
+		SuggestTitle:  newSuggestList(books, func(b models.Book) string { return b.Name }),
+	}}
+}
+
+func newSuggestList(books []models.Book, field func(models.Book) string) *suggestList {
+	unitsByValue := make(map[string]uint)
+	for _, book := range books {
+		if value := strings.TrimSpace(field(book)); value != "" {
+			unitsByValue[value] += book.UnitsSold
+		}
+	}
+
+	list := &suggestList{values: make([]Suggestion, 0, len(unitsByValue))}
+	for value, units := range unitsByValue {
+		list.values = append(list.values, Suggestion{Value: value, UnitsSold: units})
+	}
+	sort.Slice(list.values, func(a, b int) bool {
+		if list.values[a].UnitsSold != list.values[b].UnitsSold {
+			return list.values[a].UnitsSold > list.values[b].UnitsSold
+		}
+		return list.values[a].Value < list.values[b].Value
+	})
+
+	for rank, suggestion := range list.values {
+		for _, tok := range tokenize(suggestion.Value) {
+			list.keys = append(list.keys, suggestKey{key: foldText(suggestion.Value[tok.start:]), rank: rank})
+		}
+	}
+	sort.Slice(list.keys, func(a, b int) bool {
+		if list.keys[a].key != list.keys[b].key {
+			return list.keys[a].key < list.keys[b].key
+		}
+		return list.keys[a].rank < list.keys[b].rank
+	})
+
+	n := len(list.keys)
+	list.minTree = make([]int, 2*n)
+	for i := range list.keys {
+		list.minTree[n+i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		list.minTree[i] = list.lower(list.minTree[2*i], list.minTree[2*i+1])
+	}
+	return list
+}
+
+func (l *suggestList) lower(a, b int) int {
+	if l.keys[b].rank < l.keys[a].rank {
+		return b
+	}
+	return a
+}
+
+// minPosition returns the position of the lowest rank in keys[lo:hi].
+func (l *suggestList) minPosition(lo, hi int) int {
+	n := len(l.keys)
+	best := lo
+	for lo, hi = lo+n, hi+n; lo < hi; lo, hi = lo/2, hi/2 {
+		if lo&1 == 1 {
+			best = l.lower(best, l.minTree[lo])
+			lo++
+		}
+		if hi&1 == 1 {
+			hi--
+			best = l.lower(best, l.minTree[hi])
+		}
+	}
+	return best
+}
+
+// keyRange is a half-open span of keys together with its best position.
+type keyRange struct {
+	lo, hi, best int
+}
+
+type keyRangeHeap struct {
+	list   *suggestList
+	ranges []keyRange
+}
+
+func (h *keyRangeHeap) Len() int { return len(h.ranges) }
+func (h *keyRangeHeap) Less(a, b int) bool {
+	return h.list.keys[h.ranges[a].best].rank < h.list.keys[h.ranges[b].best].rank
+}
+func (h *keyRangeHeap) Swap(a, b int) { h.ranges[a], h.ranges[b] = h.ranges[b], h.ranges[a] }
+func (h *keyRangeHeap) Push(x any)    { h.ranges = append(h.ranges, x.(keyRange)) }
+func (h *keyRangeHeap) Pop() any {
+	last := h.ranges[len(h.ranges)-1]
+	h.ranges = h.ranges[:len(h.ranges)-1]
+	return last
+}
+
+func (h *keyRangeHeap) pushRange(lo, hi int) {
+	if lo < hi {
+		heap.Push(h, keyRange{lo: lo, hi: hi, best: h.list.minPosition(lo, hi)})
+	}
+}
+
+// Suggest returns up to limit values of field matching prefix, best sellers
+// first.
+func (idx *SuggestIndex) Suggest(field SuggestField, prefix string, limit int) ([]Suggestion, error) {
+	list, ok := idx.fields[field]
+	if !ok {
+		return nil, ErrInvalidSuggestField
+	}
+	prefix = foldText(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return nil, ErrEmptyPrefix
+	}
+	if limit <= 0 {
+		return []Suggestion{}, nil
+	}
+
+	start := sort.Search(len(list.keys), func(i int) bool { return list.keys[i].key >= prefix })
+	end := start + sort.Search(len(list.keys)-start, func(i int) bool {
+		return !strings.HasPrefix(list.keys[start+i].key, prefix)
+	})
+
+	// Repeatedly take the best key in the remaining spans and split around it.
+	// A value indexed under several matching words may surface more than once.
+	top := make([]int, 0, limit)
+	seen := make(map[int]struct{}, limit)
+	spans := &keyRangeHeap{list: list}
+	spans.pushRange(start, end)
+	for len(top) < limit && spans.Len() > 0 {
+		span := heap.Pop(spans).(keyRange)
+		rank := list.keys[span.best].rank
+		if _, ok := seen[rank]; !ok {
+			seen[rank] = struct{}{}
+			top = append(top, rank)
+		}
+		spans.pushRange(span.lo, span.best)
+		spans.pushRange(span.best+1, span.hi)
+	}
+
+	suggestions := make([]Suggestion, len(top))
+	for i, rank := range top {
+		suggestions[i] = list.values[rank]
+	}
+	return suggestions, nil
+}
+
+type SuggestService struct {
+	booksRepositories repositories.BooksRepository
+
+	mu       sync.Mutex
+	index    *SuggestIndex
+	lastSeen []models.Book
+}
+
+func NewSuggestService(repository repositories.BooksRepository) *SuggestService {
+	return &SuggestService{booksRepositories: repository}
+}
+
+func (s *SuggestService) Suggest(ctx context.Context, field SuggestField, prefix string, limit int) ([]Suggestion, error) {
+	if field != SuggestAuthor && field != SuggestTitle {
+		return nil, ErrInvalidSuggestField
+	}
+
+	books, err := s.booksRepositories.GetBooksProvider(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExternalServiceFailure, err)
+	}
+
+	s.mu.Lock()
+	if s.index == nil || !sameCatalog(s.lastSeen, books) {
+		s.index = NewSuggestIndex(books)
+		s.lastSeen = books
+	}
+	index := s.index
+	s.mu.Unlock()
+
+	return index.Suggest(field, prefix, limit)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestIndex_Suggest_RankedByUnitsSold(t *testing.T) {
+	// Arrange
+	index := NewSuggestIndex([]models.Book{
+		{Name: "Refactoring", Author: "Martin Fowler", UnitsSold: 100},
+		{Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 300},
+		{Name: "Clean Architecture", Author: "Robert C. Martin", UnitsSold: 50},
+		{Name: "The Martian", Author: "Andy Weir", UnitsSold: 200},
+	})
+
+	// Act
+	suggestions, err := index.Suggest(SuggestAuthor, "mar", 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []Suggestion{
+		{Value: "Robert C. Martin", UnitsSold: 350},
+		{Value: "Martin Fowler", UnitsSold: 100},
+	}, suggestions)
+}
+
+func TestSuggestIndex_Suggest_TitleWordPrefixAndAccents(t *testing.T) {
+	// Arrange
+	index := NewSuggestIndex([]models.Book{
+		{Name: "Cien años de soledad", UnitsSold: 10},
+		{Name: "El amor en los tiempos del cólera", UnitsSold: 20},
+	})
+
+	// Act
+	suggestions, err := index.Suggest(SuggestTitle, "Cole", 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []Suggestion{{Value: "El amor en los tiempos del cólera", UnitsSold: 20}}, suggestions)
+}
+
+func TestSuggestIndex_Suggest_Limit(t *testing.T) {
+	// Arrange
+	var books []models.Book
+	for i := 0; i < 20; i++ {
+		books = append(books, models.Book{Name: fmt.Sprintf("Go %02d", i), UnitsSold: uint(i)})
+	}
+	index := NewSuggestIndex(books)
+
+	// Act
+	suggestions, err := index.Suggest(SuggestTitle, "go", 3)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []Suggestion{
+		{Value: "Go 19", UnitsSold: 19},
+		{Value: "Go 18", UnitsSold: 18},
+		{Value: "Go 17", UnitsSold: 17},
+	}, suggestions)
+}
+
+func TestSuggestIndex_Suggest_Errors(t *testing.T) {
+	// Arrange
+	index := NewSuggestIndex(nil)
+
+	// Act
+	_, fieldErr := index.Suggest("isbn", "go", 10)
+	_, prefixErr := index.Suggest(SuggestTitle, "  ", 10)
+
+	// Assert
+	assert.Equal(t, ErrInvalidSuggestField, fieldErr)
+	assert.Equal(t, ErrEmptyPrefix, prefixErr)
+}
+
+func TestSuggestService_Suggest(t *testing.T) {
+	// Arrange
+	service := NewSuggestService(mockImpls.NewMockBooksRepositories())
+
+	// Act
+	suggestions, err := service.Suggest(context.Background(), SuggestTitle, "the", 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []Suggestion{
+		{Value: "The Pragmatic Programmer", UnitsSold: 13000},
+		{Value: "The Go Programming Language", UnitsSold: 5000},
+	}, suggestions)
+}
+
+func TestSuggestService_Suggest_RepositoryError(t *testing.T) {
+	// Arrange
+	service := NewSuggestService(&MockBooksRepositoryWithError{})
+
+	// Act
+	suggestions, err := service.Suggest(context.Background(), SuggestAuthor, "a", 10)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrExternalServiceFailure)
+	assert.Nil(t, suggestions)
+}
+
+func benchmarkCatalog(size int) []models.Book {
+	firstNames := []string{"Alan", "Robert", "Andrew", "Martin", "Ursula", "Gabriel", "Jane", "Kent", "Eric", "Brian"}
+	lastNames := []string{"Donovan", "Martin", "Hunt", "Fowler", "Le Guin", "García", "Austen", "Beck", "Evans", "Kernighan"}
+	words := []string{"Programming", "Language", "Clean", "Code", "Pragmatic", "Design", "Patterns", "Systems", "Go", "Architecture", "Domain", "Driven"}
+
+	books := make([]models.Book, size)
+	for i := range books {
+		books[i] = models.Book{
+			ID:        uint(i + 1),
+			Name:      fmt.Sprintf("%s %s %d", words[i%len(words)], words[(i/7)%len(words)], i),
+			Author:    fmt.Sprintf("%s %s %d", firstNames[i%len(firstNames)], lastNames[(i/3)%len(lastNames)], i%5000),
+			UnitsSold: uint((i * 7919) % 100000),
+			Price:     uint(10 + i%90),
+		}
+	}
+	return books
+}
+
+func BenchmarkSuggestIndex_Suggest(b *testing.B) {
+	index := NewSuggestIndex(benchmarkCatalog(100_000))
+
+	cases := []struct {
+		field  SuggestField
+		prefix string
+	}{
+		{SuggestAuthor, "m"},
+		{SuggestAuthor, "mart"},
+		{SuggestTitle, "p"},
+		{SuggestTitle, "clean co"},
+	}
+	for _, c := range cases {
+		b.Run(fmt.Sprintf("%s/%s", c.field, c.prefix), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := index.Suggest(c.field, c.prefix, 10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNewSuggestIndex(b *testing.B) {
+	books := benchmarkCatalog(100_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewSuggestIndex(books)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"educabot.com/bookshop/models"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// token is a folded word together with its byte span in the original text.
+type token struct {
+	term       string
+	start, end int
+}
+
+// foldText lowercases s and strips diacritics, so "Gabriel García Márquez"
+// and "gabriel garcia marquez" compare equal.
+func foldText(s string) string {
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{term: foldText(s[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: foldText(s[start:]), start: start, end: len(s)})
+	}
+	return tokens
+}
+
+// sameCatalog reports whether two catalog snapshots are the same slice, which
+// is how CatalogRefresher hands out an unchanged catalog.
+func sameCatalog(a, b []models.Book) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldText(t *testing.T) {
+	// Act
+	result := foldText("Gabriel García MÁRQUEZ")
+
+	// Assert
+	assert.Equal(t, "gabriel garcia marquez", result)
+}
+
+func TestTokenize(t *testing.T) {
+	// Act
+	tokens := tokenize("The Pragmatic-Programmer, 2nd Édition")
+
+	// Assert
+	assert.Equal(t, []token{
+		{term: "the", start: 0, end: 3},
+		{term: "pragmatic", start: 4, end: 13},
+		{term: "programmer", start: 14, end: 24},
+		{term: "2nd", start: 26, end: 29},
+		{term: "edition", start: 30, end: 38},
+	}, tokens)
+}
+
+func TestSameCatalog(t *testing.T) {
+	// Arrange
+	books := []models.Book{{ID: 1}}
+	copied := append([]models.Book(nil), books...)
+
+	// Assert
+	assert.True(t, sameCatalog(books, books))
+	assert.False(t, sameCatalog(books, copied))
+	assert.False(t, sameCatalog(nil, books))
+}
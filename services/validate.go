@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"educabot.com/bookshop/models"
+)
+
+var ErrInvalidCatalog = errors.New("invalid catalog")
+
+// CatalogIssue is one problem found in a catalog payload. Index is the
+// book's position in the payload.
+type CatalogIssue struct {
+	Index   int    `json:"index"`
+	ID      uint   `json:"id"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (i CatalogIssue) String() string {
+	return fmt.Sprintf("book %d (id %d): %s: %s", i.Index, i.ID, i.Field, i.Message)
+}
+
+// ValidateCatalog checks that every book has a unique non-zero ID, a name,
+// an author and, when present, a valid ISBN. Shared ISBNs are not issues;
+// they are reported by the duplicates endpoint.
+func ValidateCatalog(books []models.Book) []CatalogIssue {
+	issues := []CatalogIssue{}
+	seen := make(map[uint]int, len(books))
+	for index, book := range books {
+		report := func(field, message string) {
+			issues = append(issues, CatalogIssue{Index: index, ID: book.ID, Field: field, Message: message})
+		}
+
+		if book.ID == 0 {
+			report("id", "is missing")
+		} else if first, ok := seen[book.ID]; ok {
+			report("id", fmt.Sprintf("duplicates book %d", first))
+		} else {
+			seen[book.ID] = index
+		}
+		if strings.TrimSpace(book.Name) == "" {
+			report("name", "is empty")
+		}
+		if strings.TrimSpace(book.Author) == "" {
+			report("author", "is empty")
+		}
+		if book.ISBN != "" {
+			if _, err := models.NormalizeISBN(book.ISBN); err != nil {
+				report("isbn", err.Error())
+			}
+		}
+	}
+	return issues
+}
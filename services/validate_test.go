@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories/mockImpls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCatalog_Valid(t *testing.T) {
+	// Arrange
+	books, _ := mockImpls.NewMockBooksRepositories().GetBooksProvider(context.Background())
+
+	// Act
+	issues := ValidateCatalog(books)
+
+	// Assert
+	assert.Empty(t, issues)
+	assert.NotNil(t, issues)
+}
+
+func TestValidateCatalog_Issues(t *testing.T) {
+	// Arrange
+	books := []models.Book{
+		{ID: 1, Name: "First", Author: "A", ISBN: "978-0134190440"},
+		{ID: 1, Name: " ", Author: "B"},
+		{Name: "No ID", Author: "C", ISBN: "12345"},
+		{ID: 4, Name: "Shared ISBN", Author: "", ISBN: "9780134190440"},
+	}
+
+	// Act
+	issues := ValidateCatalog(books)
+
+	// Assert
+	assert.Equal(t, []CatalogIssue{
+		{Index: 1, ID: 1, Field: "id", Message: "duplicates book 0"},
+		{Index: 1, ID: 1, Field: "name", Message: "is empty"},
+		{Index: 2, ID: 0, Field: "id", Message: "is missing"},
+		{Index: 2, ID: 0, Field: "isbn", Message: models.ErrISBNLength.Error()},
+		{Index: 3, ID: 4, Field: "author", Message: "is empty"},
+	}, issues)
+	assert.Equal(t, "book 2 (id 0): isbn: isbn must have 10 or 13 digits", issues[3].String())
+}
@@ -0,0 +1,254 @@
+// Dashboard for the bookshop API. It only talks to the origin that served it
+// and builds every chart as inline SVG, so it works without network access
+// to anything else.
+(function () {
+  "use strict";
+
+  var SVG = "http://www.w3.org/2000/svg";
+  var API_KEY_STORAGE = "bookshop.apiKey";
+  var numberFormat = new Intl.NumberFormat();
+  var priceFormat = new Intl.NumberFormat(undefined, { maximumFractionDigits: 2 });
+
+  function $(id) { return document.getElementById(id); }
+
+  function text(node, value) { node.textContent = value; }
+
+  function clear(node) {
+    while (node.firstChild) node.removeChild(node.firstChild);
+  }
+
+  function show(node, visible) { node.hidden = !visible; }
+
+  function svg(tag, attrs, parent) {
+    var node = document.createElementNS(SVG, tag);
+    Object.keys(attrs).forEach(function (key) { node.setAttribute(key, attrs[key]); });
+    if (parent) parent.appendChild(node);
+    return node;
+  }
+
+  // request fetches JSON and turns problem+json bodies into Error messages.
+  function request(path, headers) {
+    return fetch(path, { headers: Object.assign({ Accept: "application/json" }, headers || {}) })
+      .then(function (response) {
+        return response.json().catch(function () { return null; }).then(function (body) {
+          if (!response.ok) {
+            var message = body && body.title ? body.title + (body.detail ? ": " + body.detail : "") : response.status + " " + response.statusText;
+            throw new Error(message);
+          }
+          return body;
+        });
+      });
+  }
+
+  function showError(node, err) {
+    text(node, err.message);
+    show(node, true);
+  }
+
+  function describeBook(book) {
+    return book.author + " · $" + priceFormat.format(book.price) + " · " + numberFormat.format(book.units_sold) + " sold";
+  }
+
+  // Summary cards and top sellers.
+  function loadMetrics() {
+    request("/v2/metrics?top_n=5")
+      .then(function (metrics) {
+        show($("metrics-error"), false);
+        text($("mean-units-sold"), numberFormat.format(metrics.mean_units_sold));
+        if (metrics.cheapest_book) {
+          text($("cheapest-name"), metrics.cheapest_book.name);
+          text($("cheapest-detail"), describeBook(metrics.cheapest_book));
+        } else {
+          text($("cheapest-name"), "–");
+        }
+        var list = $("top-sellers");
+        clear(list);
+        metrics.top_sellers.forEach(function (book) {
+          var item = document.createElement("li");
+          var name = document.createElement("strong");
+          text(name, book.name);
+          item.appendChild(name);
+          item.appendChild(document.createTextNode(" — " + describeBook(book)));
+          list.appendChild(item);
+        });
+      })
+      .catch(function (err) { showError($("metrics-error"), err); });
+  }
+
+  // Author search: count from /v2/metrics, matching books from /search and
+  // typeahead from /suggest.
+  function searchAuthor(author) {
+    var params = "author=" + encodeURIComponent(author);
+    show($("search-error"), false);
+    Promise.all([
+      request("/v2/metrics?" + params),
+      request("/search?limit=50&q=" + encodeURIComponent(author))
+    ]).then(function (results) {
+      text($("author-count"), numberFormat.format(results[0].books_written_by_author));
+      text($("author-name"), author);
+
+      var needle = author.toLowerCase();
+      var hits = results[1].hits.filter(function (hit) {
+        return hit.book.author.toLowerCase().indexOf(needle) !== -1;
+      });
+      var body = $("search-results").querySelector("tbody");
+      clear(body);
+      hits.forEach(function (hit) {
+        var row = document.createElement("tr");
+        [hit.book.name, hit.book.author, numberFormat.format(hit.book.units_sold), "$" + priceFormat.format(hit.book.price)]
+          .forEach(function (value, index) {
+            var cell = document.createElement("td");
+            if (index > 1) cell.className = "number";
+            text(cell, value);
+            row.appendChild(cell);
+          });
+        body.appendChild(row);
+      });
+      show($("search-results"), hits.length > 0);
+      show($("search-empty"), hits.length === 0);
+    }).catch(function (err) { showError($("search-error"), err); });
+  }
+
+  var suggestTimer;
+  function suggestAuthors(prefix) {
+    clearTimeout(suggestTimer);
+    if (prefix.length < 2) return;
+    suggestTimer = setTimeout(function () {
+      request("/suggest?field=author&limit=8&prefix=" + encodeURIComponent(prefix))
+        .then(function (result) {
+          var list = $("author-suggestions");
+          clear(list);
+          result.suggestions.forEach(function (suggestion) {
+            var option = document.createElement("option");
+            option.value = suggestion.value;
+            list.appendChild(option);
+          });
+        })
+        .catch(function () { /* suggestions are best effort */ });
+    }, 200);
+  }
+
+  // Price distribution charts.
+  function scale(domainMin, domainMax, rangeMin, rangeMax) {
+    var span = domainMax - domainMin || 1;
+    return function (value) { return rangeMin + (value - domainMin) / span * (rangeMax - rangeMin); };
+  }
+
+  function drawHistogram(chart, result) {
+    clear(chart);
+    var width = 640, height = 260, left = 40, right = 10, top = 10, bottom = 40;
+    var buckets = result.buckets;
+    if (buckets.length === 0) return;
+    var maxCount = Math.max.apply(null, buckets.map(function (b) { return b.count; })) || 1;
+    var x = scale(buckets[0].lower, buckets[buckets.length - 1].upper, left, width - right);
+    var y = scale(0, maxCount, height - bottom, top);
+
+    buckets.forEach(function (bucket) {
+      var x0 = x(bucket.lower), x1 = x(bucket.upper);
+      var bar = svg("rect", {
+        "class": "bar",
+        x: x0 + 1,
+        y: y(bucket.count),
+        width: Math.max(x1 - x0 - 2, 1),
+        height: height - bottom - y(bucket.count)
+      }, chart);
+      var title = svg("title", {}, bar);
+      text(title, "$" + priceFormat.format(bucket.lower) + " – $" + priceFormat.format(bucket.upper) + ": " + bucket.count + " books");
+    });
+
+    svg("line", { "class": "axis", x1: left, y1: height - bottom, x2: width - right, y2: height - bottom }, chart);
+    svg("line", { "class": "axis", x1: left, y1: top, x2: left, y2: height - bottom }, chart);
+    [0, maxCount].forEach(function (count) {
+      var label = svg("text", { x: left - 6, y: y(count) + 4, "text-anchor": "end" }, chart);
+      text(label, count);
+    });
+    var edges = buckets.map(function (b) { return b.lower; }).concat([buckets[buckets.length - 1].upper]);
+    var step = Math.ceil(edges.length / 8);
+    edges.forEach(function (edge, index) {
+      if (index % step !== 0 && index !== edges.length - 1) return;
+      var label = svg("text", { x: x(edge), y: height - bottom + 18, "text-anchor": "middle" }, chart);
+      text(label, "$" + priceFormat.format(edge));
+    });
+  }
+
+  function drawBoxPlot(chart, result) {
+    clear(chart);
+    var width = 640, left = 40, right = 10, middle = 40;
+    var lowWhisker = Math.max(result.min, result.lower_fence);
+    var highWhisker = Math.min(result.max, result.upper_fence);
+    var x = scale(result.min, result.max, left, width - right);
+
+    svg("line", { "class": "whisker", x1: x(lowWhisker), y1: middle, x2: x(result.q1), y2: middle }, chart);
+    svg("line", { "class": "whisker", x1: x(result.q3), y1: middle, x2: x(highWhisker), y2: middle }, chart);
+    [lowWhisker, highWhisker].forEach(function (value) {
+      svg("line", { "class": "whisker", x1: x(value), y1: middle - 10, x2: x(value), y2: middle + 10 }, chart);
+    });
+    svg("rect", { "class": "box", x: x(result.q1), y: middle - 18, width: Math.max(x(result.q3) - x(result.q1), 1), height: 36 }, chart);
+    svg("line", { "class": "whisker", x1: x(result.median), y1: middle - 18, x2: x(result.median), y2: middle + 18 }, chart);
+    result.outliers.forEach(function (book) {
+      var point = svg("circle", { "class": "outlier", cx: x(book.price), cy: middle, r: 4 }, chart);
+      text(svg("title", {}, point), book.name + ": $" + priceFormat.format(book.price));
+    });
+  }
+
+  function drawStats(list, result) {
+    clear(list);
+    [["Books", numberFormat.format(result.count)],
+     ["Min", "$" + priceFormat.format(result.min)],
+     ["Q1", "$" + priceFormat.format(result.q1)],
+     ["Median", "$" + priceFormat.format(result.median)],
+     ["Mean", "$" + priceFormat.format(result.mean)],
+     ["Q3", "$" + priceFormat.format(result.q3)],
+     ["Max", "$" + priceFormat.format(result.max)],
+     ["Std dev", priceFormat.format(result.std_dev)]
+    ].forEach(function (pair) {
+      var term = document.createElement("dt");
+      var value = document.createElement("dd");
+      text(term, pair[0]);
+      text(value, pair[1]);
+      list.appendChild(term);
+      list.appendChild(value);
+    });
+  }
+
+  function loadDistribution() {
+    var apiKey = $("api-key").value;
+    var query = "field=price&buckets=" + encodeURIComponent($("buckets").value) + "&mode=" + encodeURIComponent($("mode").value);
+    show($("distribution-error"), false);
+    request("/v2/metrics/distribution?" + query, { "X-API-Key": apiKey })
+      .then(function (result) {
+        sessionStorage.setItem(API_KEY_STORAGE, apiKey);
+        drawHistogram($("histogram"), result);
+        drawBoxPlot($("boxplot"), result);
+        drawStats($("distribution-stats"), result);
+        show($("distribution"), true);
+      })
+      .catch(function (err) {
+        show($("distribution"), false);
+        showError($("distribution-error"), err);
+      });
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    loadMetrics();
+
+    $("author-form").addEventListener("submit", function (event) {
+      event.preventDefault();
+      var author = $("author-input").value.trim();
+      if (author) searchAuthor(author);
+    });
+    $("author-input").addEventListener("input", function (event) {
+      suggestAuthors(event.target.value.trim());
+    });
+
+    $("distribution-form").addEventListener("submit", function (event) {
+      event.preventDefault();
+      loadDistribution();
+    });
+    var savedKey = sessionStorage.getItem(API_KEY_STORAGE);
+    if (savedKey) {
+      $("api-key").value = savedKey;
+      loadDistribution();
+    }
+  });
+})();
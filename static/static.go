@@ -0,0 +1,16 @@
+// Package static embeds the dashboard served at /ui and the API docs page
+// served at /docs.
+package static
+
+import "embed"
+
+// Dashboard holds index.html, a html/template whose {{asset "path"}} calls
+// resolve to cache-busted URLs, and the css/ and js/ assets it references.
+//
+//go:embed index.html favicon.svg css js
+var Dashboard embed.FS
+
+// Docs holds docs.html, which renders /openapi.json.
+//
+//go:embed docs.html
+var Docs embed.FS
@@ -0,0 +1,21 @@
+package testutil
+
+import (
+	"context"
+
+	"educabot.com/bookshop/models"
+)
+
+// StaticBooksRepository serves Books on every call, or fails with Err when it
+// is set.
+type StaticBooksRepository struct {
+	Books []models.Book
+	Err   error
+}
+
+func (r *StaticBooksRepository) GetBooksProvider(context.Context) ([]models.Book, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return r.Books, nil
+}
@@ -0,0 +1,26 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"educabot.com/bookshop/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticBooksRepository(t *testing.T) {
+	// Arrange
+	books := []models.Book{{ID: 1, Name: "Dune"}}
+	failure := errors.New("upstream down")
+
+	// Act
+	served, servedErr := (&StaticBooksRepository{Books: books}).GetBooksProvider(context.Background())
+	failed, failedErr := (&StaticBooksRepository{Books: books, Err: failure}).GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, servedErr)
+	assert.Equal(t, books, served)
+	assert.ErrorIs(t, failedErr, failure)
+	assert.Nil(t, failed)
+}
@@ -0,0 +1,117 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TokenPath is where TokenServer answers token requests.
+const TokenPath = "/oauth2/token"
+
+// TokenServer is a local OAuth2 token endpoint for the client credentials
+// grant. It accepts one client, authenticated with HTTP Basic or form
+// fields, and issues numbered bearer tokens.
+type TokenServer struct {
+	*httptest.Server
+	clientID     string
+	clientSecret string
+
+	mu        sync.Mutex
+	expiresIn int
+	issued    []string
+	scopes    []string
+}
+
+// NewTokenServer starts a TokenServer for clientID and clientSecret whose
+// tokens last an hour, and closes it when t ends.
+func NewTokenServer(t testing.TB, clientID, clientSecret string) *TokenServer {
+	t.Helper()
+	s := &TokenServer{clientID: clientID, clientSecret: clientSecret, expiresIn: 3600}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// TokenURL is the endpoint to configure as the OAuth2 token URL.
+func (s *TokenServer) TokenURL() string {
+	return s.URL + TokenPath
+}
+
+// SetExpiresIn changes the lifetime, in seconds, of the tokens issued next.
+func (s *TokenServer) SetExpiresIn(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresIn = seconds
+}
+
+// Issued counts the tokens issued so far.
+func (s *TokenServer) Issued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.issued)
+}
+
+// Scopes returns the scope requested with the last token.
+func (s *TokenServer) Scopes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scopes
+}
+
+// Authorize reports whether r carries a bearer token this server issued.
+// Pass it to Upstream.SetAuthorize.
+func (s *TokenServer) Authorize(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, issued := range s.issued {
+		if token == issued {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TokenServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != TokenPath || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if id != s.clientID || secret != s.clientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostFormValue("grant_type") != "client_credentials" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	s.mu.Lock()
+	token := "test-token-" + strconv.Itoa(len(s.issued)+1)
+	s.issued = append(s.issued, token)
+	s.scopes = strings.Fields(r.PostFormValue("scope"))
+	expiresIn := s.expiresIn
+	s.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]any{"access_token": token, "token_type": "Bearer", "expires_in": expiresIn})
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
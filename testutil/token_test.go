@@ -0,0 +1,85 @@
+package testutil
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"educabot.com/bookshop/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenServer_IssuesTokens(t *testing.T) {
+	// Arrange
+	tokens := NewTokenServer(t, "reports", "s3cr3t")
+	credentials := repositories.NewClientCredentials(repositories.ClientCredentialsConfig{
+		TokenURL:     tokens.TokenURL(),
+		ClientID:     "reports",
+		ClientSecret: "s3cr3t",
+		Scopes:       []string{"catalog.read"},
+	})
+
+	// Act
+	token, err := credentials.Token(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "test-token-1", token)
+	assert.Equal(t, 1, tokens.Issued())
+	assert.Equal(t, []string{"catalog.read"}, tokens.Scopes())
+}
+
+func TestTokenServer_FormClientAuthentication(t *testing.T) {
+	// Arrange
+	tokens := NewTokenServer(t, "reports", "s3cr3t")
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {"reports"}, "client_secret": {"s3cr3t"}}
+
+	// Act
+	resp, err := http.Post(tokens.TokenURL(), "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+
+	// Assert
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, tokens.Issued())
+}
+
+func TestTokenServer_RejectsUnknownClient(t *testing.T) {
+	// Arrange
+	tokens := NewTokenServer(t, "reports", "s3cr3t")
+	credentials := repositories.NewClientCredentials(repositories.ClientCredentialsConfig{TokenURL: tokens.TokenURL(), ClientID: "reports", ClientSecret: "wrong"})
+
+	// Act
+	_, err := credentials.Token(context.Background())
+
+	// Assert
+	var tokenErr *repositories.TokenError
+	assert.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, http.StatusUnauthorized, tokenErr.Status)
+	assert.Equal(t, "invalid_client", tokenErr.Code)
+	assert.Zero(t, tokens.Issued())
+}
+
+func TestUpstream_SetAuthorize(t *testing.T) {
+	// Arrange
+	tokens := NewTokenServer(t, "reports", "s3cr3t")
+	upstream := NewUpstream(t, Books())
+	upstream.SetAuthorize(tokens.Authorize)
+	anonymous := repositories.NewExternalBooksRepository(upstream.BooksURL())
+	authorized := repositories.NewExternalBooksRepositoryWithOptions(upstream.BooksURL(), repositories.ExternalBooksOptions{
+		Credentials: repositories.NewClientCredentials(repositories.ClientCredentialsConfig{TokenURL: tokens.TokenURL(), ClientID: "reports", ClientSecret: "s3cr3t"}),
+	})
+
+	// Act
+	_, anonymousErr := anonymous.GetBooksProvider(context.Background())
+	books, authorizedErr := authorized.GetBooksProvider(context.Background())
+
+	// Assert
+	var statusErr *repositories.UpstreamStatusError
+	assert.ErrorAs(t, anonymousErr, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.Code)
+	assert.NoError(t, authorizedErr)
+	assert.Equal(t, Books(), books)
+}
@@ -0,0 +1,218 @@
+// Package testutil holds fakes shared by tests across packages.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+)
+
+// BooksPath is where Upstream serves its catalog, as the mockapi does.
+const BooksPath = "/api/v1/books"
+
+// Books returns the fixture catalog the mockapi held when the tests were
+// written. Each call returns a fresh slice.
+func Books() []models.Book {
+	return []models.Book{
+		{ID: 1, Name: "The Go Programming Language", Author: "Alan Donovan", UnitsSold: 5000, Price: 40, ISBN: "978-0134190440"},
+		{ID: 2, Name: "Clean Code", Author: "Robert C. Martin", UnitsSold: 15000, Price: 50, ISBN: "978-0132350884"},
+		{ID: 3, Name: "The Pragmatic Programmer", Author: "Andrew Hunt", UnitsSold: 13000, Price: 45, ISBN: "0-201-61622-X"},
+	}
+}
+
+// LoadBooks reads a JSON, NDJSON or CSV fixture catalog and fails t when it
+// does not parse.
+func LoadBooks(t testing.TB, path string) []models.Book {
+	t.Helper()
+	repo, err := repositories.NewFileBooksRepository(path, "")
+	if err != nil {
+		t.Fatalf("loading fixture catalog: %v", err)
+	}
+	books, _ := repo.GetBooksProvider(context.Background())
+	return books
+}
+
+// Fault makes Upstream misbehave. Faults combine: Latency delays whatever
+// the other fields produce.
+type Fault struct {
+	// Latency is waited before the response headers are written.
+	Latency time.Duration
+	// Status, when set, is answered with an empty body instead of the catalog.
+	Status int
+	// Truncate announces the full Content-Length but sends only half the body.
+	Truncate bool
+	// Malformed answers with a body that is not valid JSON.
+	Malformed bool
+	// Drip writes the body one byte at a time, flushing and waiting Drip
+	// between bytes.
+	Drip time.Duration
+	// Times limits the fault to the next Times requests; zero keeps it until
+	// it is replaced.
+	Times int
+}
+
+// Upstream is a deterministic stand-in for the mockapi books endpoint. It
+// answers GET BooksPath with its catalog, paginated like the mockapi when
+// page and limit are given, and 404 for anything else.
+type Upstream struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	books     []models.Book
+	fault     Fault
+	authorize func(*http.Request) bool
+	requests  int
+}
+
+// NewUpstream starts an Upstream serving books and closes it when t ends.
+func NewUpstream(t testing.TB, books []models.Book) *Upstream {
+	t.Helper()
+	u := &Upstream{books: books}
+	u.Server = httptest.NewServer(http.HandlerFunc(u.serveHTTP))
+	t.Cleanup(u.Close)
+	return u
+}
+
+// BooksURL is the endpoint to hand to repositories.NewExternalBooksRepository.
+func (u *Upstream) BooksURL() string {
+	return u.URL + BooksPath
+}
+
+// SetBooks replaces the catalog served from the next request on.
+func (u *Upstream) SetBooks(books []models.Book) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.books = books
+}
+
+// SetFault replaces the current fault; the zero Fault clears it.
+func (u *Upstream) SetFault(fault Fault) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.fault = fault
+}
+
+// SetAuthorize makes Upstream answer 401 to catalog requests authorize
+// rejects; nil accepts every request again.
+func (u *Upstream) SetAuthorize(authorize func(*http.Request) bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.authorize = authorize
+}
+
+// Requests counts the catalog requests received so far.
+func (u *Upstream) Requests() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.requests
+}
+
+// next records a request and returns the catalog, fault and authorization
+// check that apply to it.
+func (u *Upstream) next() ([]models.Book, Fault, func(*http.Request) bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.requests++
+	fault := u.fault
+	if u.fault.Times > 0 {
+		if u.fault.Times--; u.fault.Times == 0 {
+			u.fault = Fault{}
+		}
+	}
+	return u.books, fault, u.authorize
+}
+
+func (u *Upstream) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != BooksPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	books, fault, authorize := u.next()
+	if authorize != nil && !authorize(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="books"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if !sleep(r.Context(), fault.Latency) {
+		return
+	}
+	if fault.Status != 0 {
+		w.WriteHeader(fault.Status)
+		return
+	}
+	books, ok := paginate(books, r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
+	if !ok {
+		http.Error(w, "page and limit must be positive integers", http.StatusBadRequest)
+		return
+	}
+
+	body, _ := json.Marshal(books)
+	if fault.Malformed {
+		body = []byte(`[{"id": 1,, "name": "malformed"}]`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if fault.Truncate {
+		body = body[:len(body)/2]
+	}
+	if fault.Drip == 0 {
+		w.Write(body)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for i := range body {
+		w.Write(body[i : i+1])
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !sleep(r.Context(), fault.Drip) {
+			return
+		}
+	}
+}
+
+// paginate applies the mockapi's 1-based page and limit parameters. Without
+// both, the whole catalog is returned.
+func paginate(books []models.Book, page, limit string) ([]models.Book, bool) {
+	if page == "" && limit == "" {
+		return books, true
+	}
+	p, pageErr := strconv.Atoi(page)
+	l, limitErr := strconv.Atoi(limit)
+	if pageErr != nil || limitErr != nil || p < 1 || l < 1 {
+		return nil, false
+	}
+	start := min((p-1)*l, len(books))
+	end := min(start+l, len(books))
+	return books[start:end], true
+}
+
+// sleep waits d unless ctx ends first, and reports whether it waited.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
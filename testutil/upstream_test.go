@@ -0,0 +1,157 @@
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"educabot.com/bookshop/models"
+	"educabot.com/bookshop/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstream_ServesBooks(t *testing.T) {
+	// Arrange
+	upstream := NewUpstream(t, Books())
+	repo := repositories.NewExternalBooksRepository(upstream.BooksURL())
+
+	// Act
+	books, err := repo.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Books(), books)
+	assert.Equal(t, 1, upstream.Requests())
+}
+
+func TestUpstream_SetBooks(t *testing.T) {
+	// Arrange
+	upstream := NewUpstream(t, Books())
+	repo := repositories.NewExternalBooksRepository(upstream.BooksURL())
+	replacement := []models.Book{{ID: 9, Name: "Replacement", Author: "Someone"}}
+
+	// Act
+	upstream.SetBooks(replacement)
+	books, err := repo.GetBooksProvider(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, replacement, books)
+}
+
+func TestUpstream_Paginates(t *testing.T) {
+	upstream := NewUpstream(t, Books())
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		ids    []uint
+	}{
+		{"whole catalog", "", http.StatusOK, []uint{1, 2, 3}},
+		{"first page", "?page=1&limit=2", http.StatusOK, []uint{1, 2}},
+		{"last page", "?page=2&limit=2", http.StatusOK, []uint{3}},
+		{"past the end", "?page=3&limit=2", http.StatusOK, []uint{}},
+		{"limit only", "?limit=2", http.StatusBadRequest, nil},
+		{"zero page", "?page=0&limit=2", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			resp, err := http.Get(upstream.BooksURL() + tt.query)
+
+			// Assert
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.ids == nil {
+				return
+			}
+			var books []models.Book
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&books))
+			ids := []uint{}
+			for _, book := range books {
+				ids = append(ids, book.ID)
+			}
+			assert.Equal(t, tt.ids, ids)
+		})
+	}
+}
+
+func TestUpstream_UnknownPath(t *testing.T) {
+	// Arrange
+	upstream := NewUpstream(t, Books())
+
+	// Act
+	resp, err := http.Get(upstream.URL + "/api/v1/authors")
+
+	// Assert
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Zero(t, upstream.Requests())
+}
+
+func TestUpstream_Faults(t *testing.T) {
+	tests := []struct {
+		name    string
+		fault   Fault
+		timeout time.Duration
+		want    error
+	}{
+		{"server error", Fault{Status: http.StatusServiceUnavailable}, time.Second, repositories.ErrUpstreamStatus},
+		{"truncated body", Fault{Truncate: true}, time.Second, repositories.ErrUpstreamDecode},
+		{"malformed body", Fault{Malformed: true}, time.Second, repositories.ErrUpstreamDecode},
+		{"latency", Fault{Latency: time.Second}, 50 * time.Millisecond, repositories.ErrServiceUnavailable},
+		{"slow drip", Fault{Drip: 20 * time.Millisecond}, 100 * time.Millisecond, repositories.ErrUpstreamDecode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			upstream := NewUpstream(t, Books())
+			upstream.SetFault(tt.fault)
+			repo := repositories.NewExternalBooksRepository(upstream.BooksURL())
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			// Act
+			books, err := repo.GetBooksProvider(ctx)
+
+			// Assert
+			assert.ErrorIs(t, err, tt.want)
+			assert.Nil(t, books)
+		})
+	}
+}
+
+func TestUpstream_FaultTimes(t *testing.T) {
+	// Arrange
+	upstream := NewUpstream(t, Books())
+	upstream.SetFault(Fault{Status: http.StatusInternalServerError, Times: 2})
+	repo := repositories.NewExternalBooksRepository(upstream.BooksURL())
+
+	// Act
+	var errs []error
+	for range 3 {
+		_, err := repo.GetBooksProvider(context.Background())
+		errs = append(errs, err)
+	}
+
+	// Assert
+	assert.ErrorIs(t, errs[0], repositories.ErrUpstreamStatus)
+	assert.ErrorIs(t, errs[1], repositories.ErrUpstreamStatus)
+	assert.NoError(t, errs[2])
+	assert.Equal(t, 3, upstream.Requests())
+}
+
+func TestLoadBooks(t *testing.T) {
+	// Act
+	books := LoadBooks(t, "../repositories/testdata/books.csv")
+
+	// Assert
+	assert.Equal(t, Books(), books)
+}